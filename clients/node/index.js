@@ -20,8 +20,8 @@ const
         // clientKeyFile:                  undefined,
         // clientCertFile:                 undefined,
         'grpc.primary_user_agent':         'glitchd-client-node/' + VERSION,
-        'grpc.max_send_message_length':    32 * 1024,   // Bytes.
-        'grpc.max_receive_message_length': -1           // No limit.
+        'grpc.max_send_message_length':    4 * 1024 * 1024, // Bytes. Items themselves are limited to 32KiB each.
+        'grpc.max_receive_message_length': -1               // No limit.
     };
 
 class ItemsStore {
@@ -98,23 +98,117 @@ class ItemsStore {
      *
      * @param   key     string
      * @param   value   Buffer
+     * @param   opts    Object  Optional: expectedVersion (number), mustNotExist (bool), ttl (number, seconds).
      * @return  Promise
      */
-    put (key, value) {
+    put (key, value, opts) {
         if (!value instanceof Buffer) {
             throw new Error("Values passed to put must be instances of Buffer.")
         }
 
-        return this.call('put', {key, value})
+        opts = opts || {};
+
+        return this.call('put', {
+            key,
+            value,
+            expected_version: opts.expectedVersion,
+            must_not_exist:   opts.mustNotExist,
+            ttl:              opts.ttl
+        })
+    }
+
+    /**
+     *
+     * @param   key     string
+     * @param   opts    Object  Optional: expectedVersion (number).
+     * @return  Promise
+     */
+    delete (key, opts) {
+        opts = opts || {};
+
+        return this.call('delete', {key, expected_version: opts.expectedVersion})
     }
 
     /**
      *
      * @param   key     string
+     * @param   delta   number
+     * @param   opts    Object  Optional: min (number), max (number).
      * @return  Promise
      */
-    delete (key) {
-        return this.call('delete', {key})
+    increment (key, delta, opts) {
+        opts = opts || {};
+
+        return this.call('increment', {
+            key,
+            delta,
+            has_min: opts.min !== undefined,
+            min:     opts.min,
+            has_max: opts.max !== undefined,
+            max:     opts.max
+        })
+    }
+
+    /**
+     *
+     * @param   keys    string[]
+     * @return  Promise
+     */
+    batchGet (keys) {
+        return this.call('batchGet', {keys})
+    }
+
+    /**
+     *
+     * @param   items   Object[]    Objects with a key (string), a value (Buffer) and optionally
+     *                              the version (number) the item is expected to be at each.
+     * @return  Promise
+     */
+    batchPut (items) {
+        for (const item of items) {
+            if (!(item.value instanceof Buffer)) {
+                throw new Error("Values passed to batchPut must be instances of Buffer.")
+            }
+        }
+
+        return this.call('batchPut', {items})
+    }
+
+    /**
+     *
+     * @param   keys    string[]
+     * @return  Promise
+     */
+    batchDelete (keys) {
+        return this.call('batchDelete', {keys})
+    }
+
+    /**
+     *
+     * @param   opts    Object  Optional: prefix, start, end, cursor (string), limit (number), values (bool).
+     * @return  Promise
+     */
+    scan (opts) {
+        return this.call('scan', opts || {})
+    }
+
+    /**
+     * Streams changes of the item under key - or of all items with keys starting with key,
+     * if opts.prefix is set. The returned stream emits 'data' for each event, 'error' when
+     * the watch fails or ends prematurely, and 'end' once done. Call cancel() on it to stop watching.
+     *
+     * @param   key     string
+     * @param   opts    Object  Optional: prefix (bool), afterRevision (number).
+     * @return  {grpc~ClientReadableStream}
+     */
+    watch (key, opts) {
+        opts = opts || {};
+
+        return this[SERVICE].watch({
+            key,
+            prefix:         opts.prefix,
+            after_revision: opts.afterRevision
+        }, this.createFreshMetadata())
     }
 
     /**
@@ -1,5 +0,0 @@
-// +build release
-
-package main
-
-const loglevel = "warn"
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"runtime"
 
 	"github.com/js13kgames/glitchd/server"
+	"github.com/js13kgames/glitchd/server/config"
 	"github.com/js13kgames/glitchd/server/log"
 )
 
@@ -23,20 +25,36 @@ const motd = `
 `
 
 func main() {
-	// @todo We don't have proper configs currently, so loglevels are build flag dependent and hardcoded.
+	configPath := flag.String("config", "", "Path to a YAML config file. Environment variables override its values.")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		os.Stderr.Write([]byte(err.Error() + "\n"))
+		os.Exit(1)
+	}
+
+	log.Config.Encoding = cfg.Log.Encoding
 	logger, err := log.New()
 	if err != nil {
 		os.Stderr.Write([]byte(err.Error()))
 		os.Exit(1)
 	}
-	log.SetLevel(loglevel)
+	log.SetLevel(cfg.Log.Level)
 
 	// Print our "pretty" logo.
 	fmt.Fprintf(os.Stdout, motd, server.Version, runtime.Version(), runtime.GOMAXPROCS(0))
 
-	(&Runner{
-		closing: make(chan struct{}),
-		Closed:  make(chan struct{}),
-		logger:  logger,
-	}).Run()
+	runner := &Runner{
+		closing:    make(chan struct{}),
+		Closed:     make(chan struct{}),
+		logger:     logger,
+		config:     cfg,
+		configPath: *configPath,
+	}
+
+	if err := runner.Run(); err != nil {
+		logger.Sync()
+		os.Exit(1)
+	}
 }
@@ -7,12 +7,14 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"github.com/js13kgames/glitchd/server"
+	"github.com/js13kgames/glitchd/server/config"
 	"github.com/js13kgames/glitchd/server/interfaces"
+	"github.com/js13kgames/glitchd/server/interfaces/http"
+	"github.com/js13kgames/glitchd/server/log"
 	"github.com/js13kgames/glitchd/server/metrics"
 	"github.com/js13kgames/glitchd/server/services"
 	"github.com/js13kgames/glitchd/server/services/items"
@@ -24,37 +26,31 @@ type Runner struct {
 	Closed  chan struct{}
 	logger  *zap.Logger
 	manager *services.Manager
+	// Path of the config file, if any, for it to be re-read on reloads.
+	configPath    string
+	config        *config.Config
+	certificate   *interfaces.Certificate
+	privilegedKey *http.PrivilegedKey
 }
 
-func (runner *Runner) Run() {
-	var (
-		restKey  string
-		restAddr string
-		rpcAddr  string
-		dbFile   string
-	)
-
-	restKey = os.Getenv("GLITCHD_REST_KEY")
-	if len(restKey) == 0 {
-		runner.logger.Fatal("Failed to initialize: no non-empty GLITCHD_REST_KEY envvar present")
-	}
-
-	restAddr = os.Getenv("GLITCHD_REST_ADDRESS")
-	if len(restAddr) == 0 {
-		restAddr = ":13313"
-	}
-
-	rpcAddr = os.Getenv("GLITCHD_RPC_ADDRESS")
-	if len(rpcAddr) == 0 {
-		rpcAddr = ":13312"
-	}
+// Run runs glitchd until it gets shut down by a signal or a failure of an interface. Returns
+// an error if it could not be started or was shut down due to a failure. Errors get logged
+// before they are returned.
+func (runner *Runner) Run() error {
+	cfg := runner.config
 
-	dbFile = os.Getenv("GLITCHD_DB")
-	if len(dbFile) == 0 {
-		dbFile = "glitchd.db"
+	certificate, err := runner.loadServerCertificate(cfg)
+	if err != nil {
+		runner.logger.Error("Failed to load server key pair",
+			zap.Error(err),
+			zap.String("certFile", cfg.Tls.CertFile),
+			zap.String("keyFile", cfg.Tls.KeyFile),
+		)
+		return err
 	}
 
-	certificate := runner.loadServerCertificate()
+	runner.certificate = interfaces.NewCertificate(certificate)
+	runner.privilegedKey = http.NewPrivilegedKey(cfg.PrivilegedKey)
 
 	// Write the PID or just log the PID. In any case of failure don't stop processing however -
 	// simply log a warning instead.
@@ -65,33 +61,57 @@ func (runner *Runner) Run() {
 	router := gin.New()
 	router.Use(gin.Recovery())
 
-	db, err := bolt.Open(dbFile, 0600, nil)
-	if err != nil {
-		runner.logger.Fatal(err.Error())
+	var (
+		ifaces []server.Interface
+		srvcs  []services.Service
+	)
+
+	for _, iface := range cfg.Interfaces {
+		switch iface.Kind {
+		case config.InterfaceGrpc:
+			// Unless configured otherwise, gRPC has to accept the largest messages the items service expects.
+			maxRecvMsgSize := iface.MaxRecvMsgSize
+			if maxRecvMsgSize == 0 && cfg.HasService(config.ServiceItems) {
+				maxRecvMsgSize = items.MaxRecvMsgSize
+			}
+
+			ifaces = append(ifaces, interfaces.NewGrpcServerInterface(iface.Name, iface.Addrs, runner.certificate, maxRecvMsgSize, iface.MaxSendMsgSize, runner.logger))
+
+		case config.InterfaceHttp:
+			ifaces = append(ifaces, interfaces.NewHttpServerInterface(iface.Name, iface.Addrs, runner.certificate, runner.logger))
+		}
+	}
+
+	if service, ok := cfg.Services[config.ServiceMetrics]; ok {
+		srvcs = append(srvcs, metricsSrv.NewMetricsService(metrics.NewGlobalAggregator(), runner.privilegedKey, service.GetInterfaces()))
+	}
+
+	if service, ok := cfg.Services[config.ServiceItems]; ok {
+		itemsService, err := items.NewItemsService(cfg.Db, []byte("stores"), runner.privilegedKey, service.GetInterfaces(), runner.logger)
+		if err != nil {
+			runner.logger.Error("Failed to load the items service", zap.Error(err), zap.String("engine", cfg.Db.Engine), zap.String("path", cfg.Db.Path))
+			return err
+		}
+		defer itemsService.Close()
+		srvcs = append(srvcs, itemsService)
 	}
-	defer db.Close()
 
-	// @todo Both server interfaces and services should be fully configurable (ideally services would
-	// simply define a hard or soft dependency on a particular interface and we'd infer what and how to load
-	// based on that).
 	runner.logger.Debug("Registering services")
-	runner.manager = services.NewServiceManager(runner.logger,
-		[]server.Interface{
-			interfaces.NewGrpcServerInterface([]string{rpcAddr}, certificate, runner.logger),
-			interfaces.NewHttpServerInterface([]string{restAddr}, certificate, runner.logger),
-		},
-		[]services.Service{
-			metricsSrv.NewMetricsService(metrics.NewGlobalAggregator(), restKey),
-			items.NewItemsService(db, []byte("stores"), restKey, runner.logger),
-		})
+	runner.manager = services.NewServiceManager(runner.logger, ifaces, srvcs)
 
 	runner.logger.Debug("Bootstrapping services")
-	runner.manager.Bootstrap()
+	if err := runner.manager.Bootstrap(); err != nil {
+		runner.logger.Error("Failed to bootstrap services", zap.Error(err))
+		return err
+	}
 
 	runner.logger.Debug("Running service manager")
-	go runner.manager.Run()
+	if err := runner.manager.Run(); err != nil {
+		runner.logger.Error("Failed to start", zap.Error(err))
+		return err
+	}
 
-	runner.waitForSignals()
+	return runner.waitForSignals()
 }
 
 //
@@ -110,34 +130,45 @@ func (runner *Runner) Close(gracePeriod time.Duration) {
 //
 //
 // @todo This would make much more sense on a per-interface/per-service basis.
-func (runner *Runner) loadServerCertificate() *tls.Certificate {
-	var (
-		certFile string
-		keyFile  string
-	)
-
-	certFile = os.Getenv("GLITCHD_SERVER_CERT")
-	if len(certFile) == 0 {
-		certFile = "server.crt"
+func (runner *Runner) loadServerCertificate(cfg *config.Config) (*tls.Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(cfg.Tls.CertFile, cfg.Tls.KeyFile)
+	if err != nil {
+		return nil, err
 	}
 
-	keyFile = os.Getenv("GLITCHD_SERVER_KEY")
-	if len(keyFile) == 0 {
-		keyFile = "server.key"
+	return &certificate, nil
+}
+
+// reload re-reads the config and applies the settings which can be changed at runtime - the log
+// level, the server certificate, the privileged key and the grace period. Everything else
+// requires a restart. If the config or certificate fail to load, nothing gets changed.
+func (runner *Runner) reload() {
+	cfg, err := config.Load(runner.configPath)
+	if err != nil {
+		runner.logger.Error("Failed to reload the config", zap.Error(err))
+		return
 	}
 
-	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	certificate, err := runner.loadServerCertificate(cfg)
 	if err != nil {
-		runner.logger.Fatal("Failed to load server key pair",
+		runner.logger.Error("Failed to reload the server key pair",
 			zap.Error(err),
-			zap.String("certFile", certFile),
-			zap.String("keyFile", keyFile),
+			zap.String("certFile", cfg.Tls.CertFile),
+			zap.String("keyFile", cfg.Tls.KeyFile),
 		)
-		// Not executed. logger.Fatal is an OS exit, but the compiler would complain otherwise.
-		return nil
+		return
 	}
 
-	return &certificate
+	runner.certificate.Store(certificate)
+	runner.privilegedKey.Store(cfg.PrivilegedKey)
+	log.SetLevel(cfg.Log.Level)
+
+	runner.config.Tls = cfg.Tls
+	runner.config.PrivilegedKey = cfg.PrivilegedKey
+	runner.config.Log.Level = cfg.Log.Level
+	runner.config.GracePeriod = cfg.GracePeriod
+
+	runner.logger.Info("Config reloaded", zap.String("logLevel", cfg.Log.Level))
 }
 
 //
@@ -151,20 +182,39 @@ func (runner *Runner) handleWritePidFile() error {
 	return nil
 }
 
-//
-//
-//
-func (runner *Runner) waitForSignals() {
+// waitForSignals blocks until a shutdown signal is received or an interface fails, and then shuts
+// down. In the latter case the failure is returned.
+func (runner *Runner) waitForSignals() error {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
+	defer signal.Stop(reloads)
+
 	runner.logger.Debug("Waiting for system signals")
 
-	<-signals
+	var failure error
+
+	for waiting := true; waiting; {
+		select {
+		case <-reloads:
+			runner.logger.Info("SIGHUP received, reloading config...")
+			runner.reload()
+		case <-signals:
+			waiting = false
+		case failure = <-runner.manager.Errors():
+			waiting = false
+		}
+	}
 
-	gracePeriod := time.Duration(time.Second * 30)
+	gracePeriod := runner.config.GracePeriod
 
-	runner.logger.Info("System signal received, initializing shutdown...", zap.Duration("grace", gracePeriod))
+	if failure != nil {
+		runner.logger.Error("Interface failed, initializing shutdown...", zap.Error(failure), zap.Duration("grace", gracePeriod))
+	} else {
+		runner.logger.Info("System signal received, initializing shutdown...", zap.Duration("grace", gracePeriod))
+	}
 
 	// Perform the shutdown in its own goroutine since we want to remain responsive to additional signals (and timeouts)
 	// while it's going on. Most children will spawn their own goroutines for shutdown on top of this, several in some
@@ -179,4 +229,6 @@ func (runner *Runner) waitForSignals() {
 	case <-runner.Closed:
 		runner.logger.Info("Service shutdown complete")
 	}
+
+	return failure
 }
@@ -0,0 +1,402 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config of a glitchd instance. Gets loaded from a YAML file, with environment variables
+// layered on top - see envOverrides for their names.
+type Config struct {
+	// Key of privileged (admin) requests to the REST API.
+	PrivilegedKey string        `yaml:"privilegedKey"`
+	GracePeriod   time.Duration `yaml:"gracePeriod"`
+	Log           LogConfig     `yaml:"log"`
+	Db            DbConfig      `yaml:"db"`
+	Tls           TlsConfig     `yaml:"tls"`
+	// Server interfaces to run. Services refer to them by name.
+	Interfaces []*InterfaceConfig `yaml:"interfaces"`
+	// Services to run, keyed by name.
+	Services map[string]*ServiceConfig `yaml:"services"`
+}
+
+type LogConfig struct {
+	Level    string `yaml:"level"`
+	Encoding string `yaml:"encoding"`
+}
+
+type DbConfig struct {
+	// Storage engine of the items service. The memory engine keeps nothing across restarts.
+	Engine string `yaml:"engine"`
+	// Path of the database file. Bolt only.
+	Path        string            `yaml:"path"`
+	GroupCommit GroupCommitConfig `yaml:"groupCommit"`
+	Snapshots   SnapshotsConfig   `yaml:"snapshots"`
+}
+
+// GroupCommitConfig of the items service. When enabled, concurrent writes get coalesced into a
+// single transaction, committed once it holds MaxSize writes or MaxDelay has passed since the first.
+type GroupCommitConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	MaxDelay time.Duration `yaml:"maxDelay"`
+	MaxSize  int           `yaml:"maxSize"`
+}
+
+// SnapshotsConfig of the items service. When a directory is set, a snapshot of the database gets
+// written to it every hour, retaining the latest Retain ones. Bolt only.
+type SnapshotsConfig struct {
+	Dir    string `yaml:"dir"`
+	Retain int    `yaml:"retain"`
+}
+
+type TlsConfig struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+}
+
+type InterfaceConfig struct {
+	Name  string   `yaml:"name"`
+	Kind  string   `yaml:"kind"`
+	Addrs []string `yaml:"addrs"`
+	// gRPC only. Max size of received and sent messages in bytes. 0 defers to the requirements
+	// of the enabled services for received messages, and to the gRPC default for sent ones.
+	MaxRecvMsgSize int `yaml:"maxRecvMsgSize"`
+	MaxSendMsgSize int `yaml:"maxSendMsgSize"`
+}
+
+type ServiceConfig struct {
+	// Names of the interfaces the service attaches to. Empty attaches to all of them.
+	Interfaces []string `yaml:"interfaces"`
+}
+
+// Kinds of interfaces.
+const (
+	InterfaceGrpc = "grpc"
+	InterfaceHttp = "http"
+)
+
+// Storage engines.
+const (
+	DbEngineBolt   = "bolt"
+	DbEngineMemory = "memory"
+)
+
+// Services which can be enabled.
+const (
+	ServiceMetrics = "metrics"
+	ServiceItems   = "items"
+)
+
+// Default returns the config used for anything not set by the file or the environment.
+func Default() *Config {
+	return &Config{
+		GracePeriod: 30 * time.Second,
+		Log: LogConfig{
+			Level:    defaultLogLevel,
+			Encoding: defaultLogEncoding,
+		},
+		Db: DbConfig{
+			Engine: DbEngineBolt,
+			Path:   "glitchd.db",
+			GroupCommit: GroupCommitConfig{
+				MaxDelay: 5 * time.Millisecond,
+				MaxSize:  256,
+			},
+			Snapshots: SnapshotsConfig{
+				Retain: 24,
+			},
+		},
+		Tls: TlsConfig{
+			CertFile: "server.crt",
+			KeyFile:  "server.key",
+		},
+		Interfaces: defaultInterfaces(),
+		Services:   defaultServices(),
+	}
+}
+
+func defaultInterfaces() []*InterfaceConfig {
+	return []*InterfaceConfig{
+		{Name: InterfaceGrpc, Kind: InterfaceGrpc, Addrs: []string{":13312"}},
+		{Name: InterfaceHttp, Kind: InterfaceHttp, Addrs: []string{":13313"}},
+	}
+}
+
+func defaultServices() map[string]*ServiceConfig {
+	return map[string]*ServiceConfig{
+		ServiceMetrics: {},
+		ServiceItems:   {},
+	}
+}
+
+// Load loads the config from the YAML file at the given path (if any), applies environment
+// overrides and validates the result. Unknown keys in the file are treated as errors.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Lists of interfaces and services given in the file replace the defaults instead of being
+		// merged with them, so that defaults can be left out.
+		cfg.Interfaces, cfg.Services = nil, nil
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+
+		if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("config: parsing %s: %v", path, err)
+		}
+
+		if cfg.Interfaces == nil {
+			cfg.Interfaces = defaultInterfaces()
+		}
+
+		if cfg.Services == nil {
+			cfg.Services = defaultServices()
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// envOverrides maps environment variables to the fields they override. Lists are comma separated.
+// The address and message size overrides apply to the interfaces with the default names.
+func (cfg *Config) envOverrides() map[string]interface{} {
+	overrides := map[string]interface{}{
+		"GLITCHD_REST_KEY":          &cfg.PrivilegedKey,
+		"GLITCHD_GRACE_PERIOD":      &cfg.GracePeriod,
+		"GLITCHD_SERVICES":          &cfg.Services,
+		"GLITCHD_LOG_LEVEL":         &cfg.Log.Level,
+		"GLITCHD_LOG_ENCODING":      &cfg.Log.Encoding,
+		"GLITCHD_DB":                &cfg.Db.Path,
+		"GLITCHD_DB_ENGINE":         &cfg.Db.Engine,
+		"GLITCHD_DB_GROUP_COMMIT":   &cfg.Db.GroupCommit.Enabled,
+		"GLITCHD_DB_SNAPSHOT_DIR":   &cfg.Db.Snapshots.Dir,
+		"GLITCHD_SERVER_CERT":       &cfg.Tls.CertFile,
+		"GLITCHD_SERVER_KEY":        &cfg.Tls.KeyFile,
+		"GLITCHD_RPC_ADDRESS":       nil,
+		"GLITCHD_RPC_MAX_RECV_SIZE": nil,
+		"GLITCHD_RPC_MAX_SEND_SIZE": nil,
+		"GLITCHD_REST_ADDRESS":      nil,
+	}
+
+	if iface := cfg.Interface(InterfaceGrpc); iface != nil {
+		overrides["GLITCHD_RPC_ADDRESS"] = &iface.Addrs
+		overrides["GLITCHD_RPC_MAX_RECV_SIZE"] = &iface.MaxRecvMsgSize
+		overrides["GLITCHD_RPC_MAX_SEND_SIZE"] = &iface.MaxSendMsgSize
+	}
+
+	if iface := cfg.Interface(InterfaceHttp); iface != nil {
+		overrides["GLITCHD_REST_ADDRESS"] = &iface.Addrs
+	}
+
+	return overrides
+}
+
+func (cfg *Config) applyEnv(lookup func(string) (string, bool)) error {
+	for name, field := range cfg.envOverrides() {
+		v, ok := lookup(name)
+		if !ok || v == "" {
+			continue
+		}
+
+		switch field := field.(type) {
+		case nil:
+			return fmt.Errorf("config: %s is set, but the interface it applies to is not configured", name)
+
+		case *string:
+			*field = v
+
+		case *[]string:
+			*field = splitList(v)
+
+		case *bool:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("config: %s: not a boolean: %q", name, v)
+			}
+			*field = b
+
+		case *int:
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("config: %s: not an integer: %q", name, v)
+			}
+			*field = n
+
+		case *time.Duration:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("config: %s: not a duration: %q", name, v)
+			}
+			*field = d
+
+		case *map[string]*ServiceConfig:
+			// Services enabled via the environment attach to all interfaces, unless configured
+			// otherwise in the file.
+			services := make(map[string]*ServiceConfig)
+			for _, service := range splitList(v) {
+				if services[service] = (*field)[service]; services[service] == nil {
+					services[service] = &ServiceConfig{}
+				}
+			}
+			*field = services
+		}
+	}
+
+	return nil
+}
+
+func splitList(v string) []string {
+	var list []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+
+	return list
+}
+
+// Validate reports the first problem found with the config, if any.
+func (cfg *Config) Validate() error {
+	if cfg.PrivilegedKey == "" {
+		return errors.New("config: privilegedKey (GLITCHD_REST_KEY) must be set")
+	}
+
+	if cfg.GracePeriod <= 0 {
+		return errors.New("config: gracePeriod must be positive")
+	}
+
+	switch cfg.Log.Level {
+	case "debug", "info", "warn", "warning", "error", "fatal", "panic":
+	default:
+		return fmt.Errorf("config: unknown log level %q", cfg.Log.Level)
+	}
+
+	if cfg.Log.Encoding != "json" && cfg.Log.Encoding != "console" {
+		return fmt.Errorf("config: unknown log encoding %q", cfg.Log.Encoding)
+	}
+
+	switch cfg.Db.Engine {
+	case DbEngineBolt:
+		if cfg.Db.Path == "" {
+			return errors.New("config: db.path must be set")
+		}
+	case DbEngineMemory:
+	default:
+		return fmt.Errorf("config: unknown db engine %q", cfg.Db.Engine)
+	}
+
+	if cfg.Db.GroupCommit.Enabled && (cfg.Db.GroupCommit.MaxDelay <= 0 || cfg.Db.GroupCommit.MaxSize <= 0) {
+		return errors.New("config: db.groupCommit.maxDelay and maxSize must be positive")
+	}
+
+	if cfg.Db.Snapshots.Dir != "" {
+		if cfg.Db.Engine != DbEngineBolt {
+			return errors.New("config: db.snapshots require the bolt engine")
+		}
+
+		if cfg.Db.Snapshots.Retain <= 0 {
+			return errors.New("config: db.snapshots.retain must be positive")
+		}
+	}
+
+	if cfg.Tls.CertFile == "" || cfg.Tls.KeyFile == "" {
+		return errors.New("config: tls.certFile and tls.keyFile must be set")
+	}
+
+	if len(cfg.Interfaces) == 0 {
+		return errors.New("config: no interfaces configured")
+	}
+
+	names := make(map[string]bool, len(cfg.Interfaces))
+	for _, iface := range cfg.Interfaces {
+		if iface == nil || iface.Name == "" {
+			return errors.New("config: interfaces must be named")
+		}
+
+		if names[iface.Name] {
+			return fmt.Errorf("config: interface %q configured more than once", iface.Name)
+		}
+		names[iface.Name] = true
+
+		if iface.Kind != InterfaceGrpc && iface.Kind != InterfaceHttp {
+			return fmt.Errorf("config: interface %q: unknown kind %q", iface.Name, iface.Kind)
+		}
+
+		if len(iface.Addrs) == 0 {
+			return fmt.Errorf("config: interface %q: addrs must not be empty", iface.Name)
+		}
+
+		if iface.MaxRecvMsgSize < 0 || iface.MaxSendMsgSize < 0 {
+			return fmt.Errorf("config: interface %q: max message sizes must not be negative", iface.Name)
+		}
+	}
+
+	if len(cfg.Services) == 0 {
+		return errors.New("config: no services enabled")
+	}
+
+	for name, service := range cfg.Services {
+		if name != ServiceMetrics && name != ServiceItems {
+			return fmt.Errorf("config: unknown service %q", name)
+		}
+
+		for _, iface := range service.GetInterfaces() {
+			if !names[iface] {
+				return fmt.Errorf("config: service %q: interface %q is not configured", name, iface)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Interface returns the config of the interface with the given name, or nil if there is none.
+func (cfg *Config) Interface(name string) *InterfaceConfig {
+	for _, iface := range cfg.Interfaces {
+		if iface != nil && iface.Name == name {
+			return iface
+		}
+	}
+
+	return nil
+}
+
+// HasService reports whether the service with the given name is enabled.
+func (cfg *Config) HasService(name string) bool {
+	_, ok := cfg.Services[name]
+	return ok
+}
+
+// GetInterfaces returns the names of the interfaces the service attaches to. Safe to call on nil,
+// which is what services enabled with an empty entry in the file decode to.
+func (service *ServiceConfig) GetInterfaces() []string {
+	if service == nil {
+		return nil
+	}
+
+	return service.Interfaces
+}
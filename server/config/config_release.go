@@ -0,0 +1,6 @@
+// +build release
+
+package config
+
+const defaultLogLevel = "warn"
+const defaultLogEncoding = "json"
@@ -3,8 +3,9 @@ package server
 import "time"
 
 type Runnable interface {
-	// Starts tasks the underlying runnable to start processing.
-	Start()
+	// Starts tasks the underlying runnable to start processing. Returns an error if it could not
+	// be started, in which case it has not started anything which would need stopping.
+	Start() error
 	// Stops the underlying runnable from further processing. If deadline is nil, the intent is a
 	// forceful close. Otherwise attempts a graceful close.
 	Stop(deadline *time.Time)
@@ -12,6 +13,11 @@ type Runnable interface {
 
 type Interface interface {
 	Runnable
+	// GetName returns the name the interface is referred to by, eg. in service dependencies. Unique.
+	GetName() string
 	// GetKind returns what kind of a server interface this is in human readable form.
 	GetKind() string
+	// Errors reports failures of the interface after it has been started, eg. a listener which
+	// stopped serving unexpectedly. It does not get closed.
+	Errors() <-chan error
 }
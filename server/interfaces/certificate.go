@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"crypto/tls"
+	"sync/atomic"
+)
+
+// Certificate holds the server certificate shared by interfaces. It can be swapped at runtime,
+// eg. when it gets rotated, without restarting the interfaces - new TLS handshakes pick up the
+// current certificate, while established connections remain unaffected.
+type Certificate struct {
+	v atomic.Value
+}
+
+func NewCertificate(cert *tls.Certificate) *Certificate {
+	c := &Certificate{}
+	c.Store(cert)
+	return c
+}
+
+func (c *Certificate) Store(cert *tls.Certificate) {
+	c.v.Store(cert)
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.v.Load().(*tls.Certificate), nil
+}
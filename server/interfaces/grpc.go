@@ -3,6 +3,7 @@ package interfaces
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"net"
 	"strings"
 	"sync/atomic"
@@ -17,37 +18,58 @@ import (
 //
 //
 type GrpcServerInterface struct {
-	isClosing         *uint32
-	addrs             []string
-	server            *grpc.Server
-	logger            *zap.Logger
-	interceptorsUnary []grpc.UnaryServerInterceptor
+	name               string
+	isClosing          *uint32
+	addrs              []string
+	errs               chan error
+	server             *grpc.Server
+	logger             *zap.Logger
+	interceptorsUnary  []grpc.UnaryServerInterceptor
+	interceptorsStream []grpc.StreamServerInterceptor
 }
 
 //
 //
 //
-func NewGrpcServerInterface(addrs []string, cert *tls.Certificate, logger *zap.Logger) *GrpcServerInterface {
+func NewGrpcServerInterface(name string, addrs []string, cert *Certificate, maxRecvMsgSize, maxSendMsgSize int, logger *zap.Logger) *GrpcServerInterface {
 	iface := &GrpcServerInterface{
+		name:      name,
 		isClosing: new(uint32),
 		addrs:     addrs,
+		errs:      make(chan error, len(addrs)),
 		logger:    logger,
 	}
 
-	iface.server = grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.Creds(credentials.NewTLS(&tls.Config{
-			ClientAuth:   tls.NoClientCert,
-			Certificates: []tls.Certificate{*cert},
+			ClientAuth:     tls.NoClientCert,
+			GetCertificate: cert.GetCertificate,
 		})),
 
-		// @todo Separate on a per-service basis.
-		grpc.MaxRecvMsgSize(32*1024),
 		grpc.UnaryInterceptor(iface.interceptUnary),
-	)
+		grpc.StreamInterceptor(iface.interceptStream),
+	}
+
+	// @todo Separate on a per-service basis.
+	// 0 retains the gRPC defaults.
+	if maxRecvMsgSize != 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(maxRecvMsgSize))
+	}
+
+	if maxSendMsgSize != 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(maxSendMsgSize))
+	}
+
+	iface.server = grpc.NewServer(opts...)
 
 	return iface
 }
 
+// Interface impl.
+func (iface *GrpcServerInterface) GetName() string {
+	return iface.name
+}
+
 // Interface impl.
 func (iface *GrpcServerInterface) GetKind() string {
 	return "gRPC"
@@ -70,8 +92,17 @@ func (iface *GrpcServerInterface) PrependUnaryInterceptor(interceptor grpc.Unary
 	iface.interceptorsUnary = append([]grpc.UnaryServerInterceptor{interceptor}, iface.interceptorsUnary...)
 }
 
+// Note: Same caveats apply as for the unary interceptor chain.
+func (iface *GrpcServerInterface) PushStreamInterceptor(interceptor grpc.StreamServerInterceptor) {
+	iface.interceptorsStream = append(iface.interceptorsStream, interceptor)
+}
+
+func (iface *GrpcServerInterface) PrependStreamInterceptor(interceptor grpc.StreamServerInterceptor) {
+	iface.interceptorsStream = append([]grpc.StreamServerInterceptor{interceptor}, iface.interceptorsStream...)
+}
+
 // Interface impl.
-func (iface *GrpcServerInterface) Start() {
+func (iface *GrpcServerInterface) Start() error {
 	// Currently we can get by without reflections. And if we do need them, this needs
 	// to be moved out of serve into a bootstrap procedure.
 	// reflection.Register(iface.server)
@@ -82,14 +113,15 @@ func (iface *GrpcServerInterface) Start() {
 	// stopgap measure.
 	atomic.StoreUint32(iface.isClosing, 0)
 
-	// Bind to each configured address and start serving incoming connections.
-	for _, addr := range iface.addrs {
-		go func(addr string) {
-			listener, err := net.Listen("tcp", addr)
-			if err != nil {
-				iface.logger.Fatal("Failed to bind", zap.Error(err))
-			}
+	// Bind to all configured addresses before serving on any of them, so that failing to bind
+	// to one of them does not leave the others serving.
+	listeners, err := listenAll(iface.addrs)
+	if err != nil {
+		return err
+	}
 
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
 			if err := iface.server.Serve(listener); err != nil {
 				// @todo There's an issue with shutdowns (either in std or in gRPC) - which I can't yet pinpoint -
 				// which causes Serve (the underlying TCP listener to be more precise) to return
@@ -105,15 +137,28 @@ func (iface *GrpcServerInterface) Start() {
 					return
 				}
 
-				iface.logger.Fatal("Failed to serve", zap.Error(err))
+				// Stopped before it got to serve on this listener.
+				if err == grpc.ErrServerStopped {
+					return
+				}
+
+				// Buffered for one error per listener, so this never blocks.
+				iface.errs <- fmt.Errorf("failed to serve on %s: %v", listener.Addr(), err)
 			}
-		}(addr)
+		}(listener)
 	}
+
+	return nil
+}
+
+// Interface impl.
+func (iface *GrpcServerInterface) Errors() <-chan error {
+	return iface.errs
 }
 
 // Interface impl.
-// @todo gRPC does not support deadlines on its own. We could wrap some timers given lots of spare time,
-// but otherwise the main Runner will take care of imposing a hard deadline on the whole process.
+// gRPC does not support deadlines on its own, so if pending RPCs don't finish gracefully before
+// the deadline, the server gets stopped forcefully - which also makes GracefulStop return.
 func (iface *GrpcServerInterface) Stop(deadline *time.Time) {
 	atomic.StoreUint32(iface.isClosing, 1)
 
@@ -122,7 +167,22 @@ func (iface *GrpcServerInterface) Stop(deadline *time.Time) {
 		return
 	}
 
-	iface.server.GracefulStop()
+	stopped := make(chan struct{})
+	go func() {
+		iface.server.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(time.Until(*deadline))
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+	case <-timer.C:
+		iface.logger.Warn("Graceful stop exceeded the deadline, stopping forcefully", zap.String("interface", iface.name))
+		iface.server.Stop()
+		<-stopped
+	}
 }
 
 //
@@ -161,3 +221,34 @@ func (iface *GrpcServerInterface) interceptUnary(ctx context.Context, req interf
 	// n == 0
 	return handler(ctx, req)
 }
+
+//
+//
+//
+func (iface *GrpcServerInterface) interceptStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	// See the notes in interceptUnary - the chain works the same way.
+	n := len(iface.interceptorsStream)
+
+	if n > 1 {
+		i := 0
+		x := n - 1
+		var chainHandler grpc.StreamHandler
+		chainHandler = func(currentSrv interface{}, currentStream grpc.ServerStream) error {
+			// If it's the last interceptor, call the actual handler.
+			if i == x {
+				return handler(currentSrv, currentStream)
+			}
+			i++
+			return iface.interceptorsStream[i](currentSrv, currentStream, info, chainHandler)
+		}
+
+		return iface.interceptorsStream[0](srv, ss, info, chainHandler)
+	}
+
+	if n == 1 {
+		return iface.interceptorsStream[0](srv, ss, info, handler)
+	}
+
+	// n == 0
+	return handler(srv, ss)
+}
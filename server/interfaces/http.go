@@ -3,6 +3,7 @@ package interfaces
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"net"
 	"net/http"
 	"sync"
@@ -17,61 +18,94 @@ func init() {
 }
 
 type HttpServerInterface struct {
-	addrs   []string
-	server  *http.Server
-	servers []http.Server
-	logger  *zap.Logger
+	name      string
+	addrs     []string
+	errs      chan error
+	handler   *gin.Engine
+	tlsConfig *tls.Config
+	logger    *zap.Logger
+	// One server per address, populated by Start.
+	mu      sync.Mutex
+	servers []*http.Server
 }
 
-func NewHttpServerInterface(addrs []string, cert *tls.Certificate, logger *zap.Logger) *HttpServerInterface {
+func NewHttpServerInterface(name string, addrs []string, cert *Certificate, logger *zap.Logger) *HttpServerInterface {
 	// @todo The recovery middleware, just as any other potential global middleware, should probably be moved
 	// out and registered elsewhere.
 	handler := gin.New()
 	handler.Use(gin.Recovery())
 
 	return &HttpServerInterface{
-		addrs:  addrs,
-		logger: logger,
-		server: &http.Server{
-			Handler: handler,
-			TLSConfig: &tls.Config{
-				Certificates: []tls.Certificate{*cert},
-			},
+		name:    name,
+		addrs:   addrs,
+		errs:    make(chan error, len(addrs)),
+		handler: handler,
+		tlsConfig: &tls.Config{
+			GetCertificate: cert.GetCertificate,
 		},
+		logger: logger,
 	}
 }
 
+func (iface *HttpServerInterface) GetName() string {
+	return iface.name
+}
+
 func (iface *HttpServerInterface) GetKind() string {
 	return "HTTP"
 }
 
 func (iface *HttpServerInterface) GetHandler() *gin.Engine {
-	return iface.server.Handler.(*gin.Engine)
+	return iface.handler
 }
 
 // Interface impl.
-func (iface *HttpServerInterface) Start() {
-	for _, addr := range iface.addrs {
-		go func(addr string) {
-			listener, err := net.Listen("tcp", addr)
-			if err != nil {
-				iface.logger.Fatal("Failed to bind", zap.Error(err))
-			}
+func (iface *HttpServerInterface) Start() error {
+	// See the notes in GrpcServerInterface.Start() - same approach.
+	listeners, err := listenAll(iface.addrs)
+	if err != nil {
+		return err
+	}
+
+	iface.mu.Lock()
+	defer iface.mu.Unlock()
+
+	for _, listener := range listeners {
+		// Separate servers, so that each can be shut down on its own - but all share the handler
+		// and the TLS config.
+		server := &http.Server{
+			Handler:   iface.handler,
+			TLSConfig: iface.tlsConfig,
+		}
+		iface.servers = append(iface.servers, server)
 
-			if err := iface.server.ServeTLS(listener, "", ""); err != nil {
-				iface.logger.Fatal("Failed to serve", zap.Error(err))
+		go func(listener net.Listener) {
+			if err := server.ServeTLS(listener, "", ""); err != nil && err != http.ErrServerClosed {
+				iface.errs <- fmt.Errorf("failed to serve on %s: %v", listener.Addr(), err)
 			}
-		}(addr)
+		}(listener)
 	}
+
+	return nil
+}
+
+// Interface impl.
+func (iface *HttpServerInterface) Errors() <-chan error {
+	return iface.errs
 }
 
 // Interface impl.
 func (iface *HttpServerInterface) Stop(deadline *time.Time) {
+	iface.mu.Lock()
+	servers := iface.servers
+	iface.servers = nil
+	iface.mu.Unlock()
+
 	var wg sync.WaitGroup
-	wg.Add(len(iface.servers))
+	wg.Add(len(servers))
 
 	if deadline == nil {
-		iface.stopForce(&wg)
+		iface.stopForce(servers, &wg)
 		// Stop() itself will most likely be called in a sub goroutine so we want to prevent
 		// exit until all servers have finished their on-shutdown procedures, even if
 		// it's a forceful close.
@@ -80,16 +114,16 @@ func (iface *HttpServerInterface) Stop(deadline *time.Time) {
 	}
 
 	ctx, cancel := context.WithDeadline(context.Background(), *deadline)
-	iface.stopGrace(ctx, &wg)
+	iface.stopGrace(ctx, servers, &wg)
 	wg.Wait()
 	cancel()
 }
 
-// stopForce immediately closes all active servers grouped in this interface, effectively
-// closing all opened listeners and dropping all peer connections.
-func (iface *HttpServerInterface) stopForce(wg *sync.WaitGroup) {
-	for _, server := range iface.servers {
-		go func(server http.Server) {
+// stopForce immediately closes all given servers, effectively closing all opened listeners
+// and dropping all peer connections.
+func (iface *HttpServerInterface) stopForce(servers []*http.Server, wg *sync.WaitGroup) {
+	for _, server := range servers {
+		go func(server *http.Server) {
 			if err := server.Close(); err != nil {
 				// Non-fatal.
 				iface.logger.Error("Failed to close forcefully", zap.Error(err))
@@ -99,15 +133,19 @@ func (iface *HttpServerInterface) stopForce(wg *sync.WaitGroup) {
 	}
 }
 
-// stopGrace closes all active servers grouped in this interface while trying to allow all
-// opened listeners and peer connections to gracefully finish their tasks up to a deadline
-// defined by the passed in Context.
-func (iface *HttpServerInterface) stopGrace(ctx context.Context, wg *sync.WaitGroup) {
-	for _, server := range iface.servers {
-		go func(server http.Server) {
+// stopGrace closes all given servers while trying to allow all opened listeners and peer
+// connections to gracefully finish their tasks up to a deadline defined by the passed in
+// Context. Connections still active once it passes get closed forcefully.
+func (iface *HttpServerInterface) stopGrace(ctx context.Context, servers []*http.Server, wg *sync.WaitGroup) {
+	for _, server := range servers {
+		go func(server *http.Server) {
 			if err := server.Shutdown(ctx); err != nil {
-				// Non-fatal.
-				iface.logger.Error("Failed to close gracefully", zap.Error(err))
+				iface.logger.Warn("Graceful shutdown exceeded the deadline, closing forcefully", zap.Error(err))
+
+				if err := server.Close(); err != nil {
+					// Non-fatal.
+					iface.logger.Error("Failed to close forcefully", zap.Error(err))
+				}
 			}
 			wg.Done()
 		}(server)
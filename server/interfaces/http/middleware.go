@@ -3,6 +3,7 @@ package http
 import (
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,7 +27,27 @@ func BearerTokenInterceptor(ctx *gin.Context) {
 	ctx.Set("token", auth[7:])
 }
 
-func PrivilegedTokenVerifier(key string) gin.HandlerFunc {
+// PrivilegedKey holds the key of privileged requests. It can be swapped at runtime, taking effect
+// for all subsequent requests.
+type PrivilegedKey struct {
+	v atomic.Value
+}
+
+func NewPrivilegedKey(key string) *PrivilegedKey {
+	k := &PrivilegedKey{}
+	k.Store(key)
+	return k
+}
+
+func (k *PrivilegedKey) Load() string {
+	return k.v.Load().(string)
+}
+
+func (k *PrivilegedKey) Store(key string) {
+	k.v.Store(key)
+}
+
+func PrivilegedTokenVerifier(key *PrivilegedKey) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token, ok := ctx.Keys["token"].(string)
 		if !ok {
@@ -35,7 +56,7 @@ func PrivilegedTokenVerifier(key string) gin.HandlerFunc {
 			// but the request was not aborted regardless.
 			panic("Cannot verify privileged key - 'token' is not set in the request context.")
 		}
-		if token != key {
+		if token != key.Load() {
 			ctx.AbortWithStatus(http.StatusForbidden)
 			return
 		}
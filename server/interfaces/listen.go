@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"fmt"
+	"net"
+)
+
+// listenAll binds to all of the given addresses. If any of them fails, the listeners bound
+// so far get closed again, so that a failed Start does not leave anything behind.
+func listenAll(addrs []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+
+			return nil, fmt.Errorf("failed to bind %s: %v", addr, err)
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
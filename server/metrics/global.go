@@ -7,12 +7,19 @@ import (
 	"github.com/js13kgames/glitchd/server"
 )
 
+// Interfaces requests get tracked by, separately.
+const (
+	InterfaceGrpc = "grpc"
+	InterfaceHttp = "http"
+)
+
 type GlobalAggregator struct {
 	startTime time.Time
 	pid       int
 	runtime   RuntimeInfo
 	hostname  string
 	requests  RequestsAggregator
+	latency   *LatencyAggregator
 }
 
 func NewGlobalAggregator() *GlobalAggregator {
@@ -24,6 +31,7 @@ func NewGlobalAggregator() *GlobalAggregator {
 		runtime:   newRuntimeInfo(),
 		startTime: time.Now(),
 		requests:  *NewRequestsAggregator(),
+		latency:   NewLatencyAggregator(),
 	}
 }
 
@@ -31,25 +39,39 @@ func (a *GlobalAggregator) Bootstrap(ticks server.TickManager) {
 	a.requests.Bootstrap(ticks)
 }
 
-func (a *GlobalAggregator) BeginRequest() {
-	a.requests.Begin()
+// BeginRequest marks the start of a request to the given interface. Every call must be followed
+// by a call to EndRequest once the request has been handled, for the in-flight gauge to remain accurate.
+func (a *GlobalAggregator) BeginRequest(iface string) {
+	a.requests.Begin(iface)
+}
+
+func (a *GlobalAggregator) EndRequest(iface string) {
+	a.requests.End(iface)
+}
+
+// ObserveRequest records the latency and resulting status code of a request to the given route
+// (eg. full gRPC method or HTTP route template) of an interface.
+func (a *GlobalAggregator) ObserveRequest(iface, route, code string, elapsed time.Duration) {
+	a.latency.Observe(iface, route, code, elapsed)
 }
 
-func (a *GlobalAggregator) EndRequest() {
-	a.requests.End()
+// History returns the recorded history of the global metrics within the given time range.
+func (a *GlobalAggregator) History(from, to time.Time, resolution Resolution) *SeriesSnapshot {
+	return a.requests.History(from, to, resolution)
 }
 
 type GlobalSnapshot struct {
-	Pid      int               `json:"pid"`
-	Version  string            `json:"version"`
-	Branch   string            `json:"branch"`
-	Commit   string            `json:"commit"`
-	Hostname string            `json:"hostname"`
-	Runtime  RuntimeInfo       `json:"runtime"`
-	Memory   *MemorySnapshot   `json:"memory"`
-	TimeNow  time.Time         `json:"now"`
-	TimeUp   float64           `json:"uptime"`
-	Requests *RequestsSnapshot `json:"requests"`
+	Pid      int                `json:"pid"`
+	Version  string             `json:"version"`
+	Branch   string             `json:"branch"`
+	Commit   string             `json:"commit"`
+	Hostname string             `json:"hostname"`
+	Runtime  RuntimeInfo        `json:"runtime"`
+	Memory   *MemorySnapshot    `json:"memory"`
+	TimeNow  time.Time          `json:"now"`
+	TimeUp   float64            `json:"uptime"`
+	Requests *RequestsSnapshot  `json:"requests"`
+	Latency  []*LatencySnapshot `json:"latency"`
 }
 
 func (a *GlobalAggregator) Collect() interface{} {
@@ -66,5 +88,25 @@ func (a *GlobalAggregator) Collect() interface{} {
 		TimeNow:  now,
 		TimeUp:   now.Sub(a.startTime).Seconds(),
 		Requests: a.requests.Collect(),
+		Latency:  a.latency.Collect(),
 	}
 }
+
+// WritePrometheus writes the global metrics in the Prometheus text exposition format.
+func (a *GlobalAggregator) WritePrometheus(w *PrometheusWriter) {
+	w.Family("glitchd_build_info", "gauge", "Build and runtime information, as labels.")
+	w.Sample("glitchd_build_info", 1,
+		"version", server.Version,
+		"branch", server.Branch,
+		"commit", server.Commit,
+		"goversion", a.runtime.Version,
+		"hostname", a.hostname,
+	)
+
+	w.Family("glitchd_start_time_seconds", "gauge", "Start time of the process since the Unix epoch, in seconds.")
+	w.Sample("glitchd_start_time_seconds", float64(a.startTime.UnixNano())/1e9)
+
+	newMemorySnapshot().writePrometheus(w)
+	a.requests.Collect().writePrometheus(w)
+	writeLatencyPrometheus(w, a.latency.Collect())
+}
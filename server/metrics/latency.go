@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Upper bounds of the latency histogram buckets, in seconds. Requests slower than the last bound
+// fall into an implicit +Inf bucket. The resolution is highest around the 1-2ms we aim for.
+var latencyBounds = []float64{
+	0.0001, 0.00025, 0.0005, 0.001, 0.002, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// LatencyAggregator records the latency and status codes of requests per route - eg. the full
+// gRPC method or the HTTP route template - since startup.
+type LatencyAggregator struct {
+	mu     sync.RWMutex
+	routes map[routeKey]*routeStats
+}
+
+type routeKey struct {
+	iface string
+	route string
+}
+
+type routeStats struct {
+	sum     uint64   // Nanoseconds. First, for 64-bit alignment of atomic ops.
+	buckets []uint64 // Non-cumulative. len(latencyBounds) + 1 for +Inf.
+	mu      sync.Mutex
+	codes   map[string]uint64
+}
+
+func NewLatencyAggregator() *LatencyAggregator {
+	return &LatencyAggregator{
+		routes: make(map[routeKey]*routeStats),
+	}
+}
+
+// Observe records a request to the given route of an interface, which completed with the given
+// status code after elapsed time.
+func (a *LatencyAggregator) Observe(iface, route, code string, elapsed time.Duration) {
+	key := routeKey{iface, route}
+
+	a.mu.RLock()
+	stats := a.routes[key]
+	a.mu.RUnlock()
+
+	if stats == nil {
+		a.mu.Lock()
+		if stats = a.routes[key]; stats == nil {
+			stats = &routeStats{
+				buckets: make([]uint64, len(latencyBounds)+1),
+				codes:   make(map[string]uint64),
+			}
+			a.routes[key] = stats
+		}
+		a.mu.Unlock()
+	}
+
+	seconds := elapsed.Seconds()
+	atomic.AddUint64(&stats.buckets[sort.SearchFloat64s(latencyBounds, seconds)], 1)
+	atomic.AddUint64(&stats.sum, uint64(elapsed))
+
+	stats.mu.Lock()
+	stats.codes[code]++
+	stats.mu.Unlock()
+}
+
+type LatencySnapshot struct {
+	Interface string            `json:"interface"`
+	Route     string            `json:"route"`
+	Count     uint64            `json:"count"`
+	Sum       float64           `json:"sum"`
+	P50       float64           `json:"p50"`
+	P95       float64           `json:"p95"`
+	P99       float64           `json:"p99"`
+	Codes     map[string]uint64 `json:"codes"`
+	buckets   []uint64
+}
+
+// Collect returns a snapshot of every route, ordered by interface and route. Latencies are in seconds.
+// Percentiles are estimated by interpolating within the histogram buckets.
+func (a *LatencyAggregator) Collect() []*LatencySnapshot {
+	a.mu.RLock()
+	snapshots := make([]*LatencySnapshot, 0, len(a.routes))
+	for key, stats := range a.routes {
+		snapshot := &LatencySnapshot{
+			Interface: key.iface,
+			Route:     key.route,
+			Sum:       time.Duration(atomic.LoadUint64(&stats.sum)).Seconds(),
+			Codes:     make(map[string]uint64),
+			buckets:   make([]uint64, len(stats.buckets)),
+		}
+
+		for i := range stats.buckets {
+			snapshot.buckets[i] = atomic.LoadUint64(&stats.buckets[i])
+			snapshot.Count += snapshot.buckets[i]
+		}
+
+		stats.mu.Lock()
+		for code, n := range stats.codes {
+			snapshot.Codes[code] = n
+		}
+		stats.mu.Unlock()
+
+		snapshot.P50 = snapshot.quantile(0.5)
+		snapshot.P95 = snapshot.quantile(0.95)
+		snapshot.P99 = snapshot.quantile(0.99)
+
+		snapshots = append(snapshots, snapshot)
+	}
+	a.mu.RUnlock()
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Interface != snapshots[j].Interface {
+			return snapshots[i].Interface < snapshots[j].Interface
+		}
+		return snapshots[i].Route < snapshots[j].Route
+	})
+
+	return snapshots
+}
+
+// quantile estimates the q-quantile by linear interpolation within the bucket it falls into.
+// Quantiles falling into the +Inf bucket are reported as the highest finite bound.
+func (s *LatencySnapshot) quantile(q float64) float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	rank := q * float64(s.Count)
+	var seen float64
+
+	for i, n := range s.buckets {
+		if n == 0 || seen+float64(n) < rank {
+			seen += float64(n)
+			continue
+		}
+
+		if i == len(latencyBounds) {
+			return latencyBounds[i-1]
+		}
+
+		lower := 0.0
+		if i != 0 {
+			lower = latencyBounds[i-1]
+		}
+
+		return lower + (latencyBounds[i]-lower)*(rank-seen)/float64(n)
+	}
+
+	return latencyBounds[len(latencyBounds)-1]
+}
+
+func writeLatencyPrometheus(w *PrometheusWriter, snapshots []*LatencySnapshot) {
+	w.Family("glitchd_request_duration_seconds", "histogram", "Latency of requests per interface and route, in seconds.")
+	for _, s := range snapshots {
+		var cumulative uint64
+		for i, n := range s.buckets {
+			cumulative += n
+
+			le := "+Inf"
+			if i < len(latencyBounds) {
+				le = strconv.FormatFloat(latencyBounds[i], 'g', -1, 64)
+			}
+
+			w.Sample("glitchd_request_duration_seconds_bucket", float64(cumulative), "interface", s.Interface, "route", s.Route, "le", le)
+		}
+		w.Sample("glitchd_request_duration_seconds_sum", s.Sum, "interface", s.Interface, "route", s.Route)
+		w.Sample("glitchd_request_duration_seconds_count", float64(s.Count), "interface", s.Interface, "route", s.Route)
+	}
+
+	w.Family("glitchd_responses_total", "counter", "Number of responses per interface, route and status code.")
+	for _, s := range snapshots {
+		codes := make([]string, 0, len(s.Codes))
+		for code := range s.Codes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+
+		for _, code := range codes {
+			w.Sample("glitchd_responses_total", float64(s.Codes[code]), "interface", s.Interface, "route", s.Route, "code", code)
+		}
+	}
+}
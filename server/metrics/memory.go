@@ -36,8 +36,52 @@ func newMemorySnapshot() *MemorySnapshot {
 		HeapIdle:     mem.HeapIdle,
 		HeapInUse:    mem.HeapInuse,
 		HeapReleased: mem.HeapReleased,
+		HeapObjects:  mem.HeapObjects,
 		PauseTotalNs: mem.PauseTotalNs,
 		NumGC:        mem.NumGC,
 		NumGoroutine: runtime.NumGoroutine(),
 	}
 }
+
+func (s *MemorySnapshot) writePrometheus(w *PrometheusWriter) {
+	gauges := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"glitchd_memory_alloc_bytes", "Bytes of allocated heap objects.", s.Alloc},
+		{"glitchd_memory_sys_bytes", "Bytes of memory obtained from the OS.", s.Sys},
+		{"glitchd_memory_heap_alloc_bytes", "Bytes of allocated heap objects.", s.HeapAlloc},
+		{"glitchd_memory_heap_sys_bytes", "Bytes of heap memory obtained from the OS.", s.HeapSys},
+		{"glitchd_memory_heap_idle_bytes", "Bytes in idle (unused) heap spans.", s.HeapIdle},
+		{"glitchd_memory_heap_inuse_bytes", "Bytes in in-use heap spans.", s.HeapInUse},
+		{"glitchd_memory_heap_released_bytes", "Bytes of physical memory returned to the OS.", s.HeapReleased},
+		{"glitchd_memory_heap_objects", "Number of allocated heap objects.", s.HeapObjects},
+		{"glitchd_goroutines", "Number of goroutines that currently exist.", uint64(s.NumGoroutine)},
+	}
+
+	for _, g := range gauges {
+		w.Family(g.name, "gauge", g.help)
+		w.Sample(g.name, float64(g.value))
+	}
+
+	counters := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"glitchd_memory_alloc_bytes_total", "Cumulative bytes allocated for heap objects.", s.TotalAlloc},
+		{"glitchd_memory_lookups_total", "Number of pointer lookups performed by the runtime.", s.Lookups},
+		{"glitchd_memory_mallocs_total", "Cumulative count of heap objects allocated.", s.Mallocs},
+		{"glitchd_memory_frees_total", "Cumulative count of heap objects freed.", s.Frees},
+		{"glitchd_gc_cycles_total", "Number of completed GC cycles.", uint64(s.NumGC)},
+	}
+
+	for _, c := range counters {
+		w.Family(c.name, "counter", c.help)
+		w.Sample(c.name, float64(c.value))
+	}
+
+	w.Family("glitchd_gc_pause_seconds_total", "counter", "Cumulative time spent in GC stop-the-world pauses, in seconds.")
+	w.Sample("glitchd_gc_pause_seconds_total", float64(s.PauseTotalNs)/1e9)
+}
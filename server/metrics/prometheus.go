@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PrometheusCollector writes metrics to the given writer. Each metric family has to be written
+// in one go - a Family followed by all its Samples.
+type PrometheusCollector func(w *PrometheusWriter)
+
+// PrometheusWriter writes metrics in the Prometheus text exposition format (version 0.0.4).
+// Write errors are sticky - once one occurs, all further writes are no-ops and Flush returns it.
+type PrometheusWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func NewPrometheusWriter(w io.Writer) *PrometheusWriter {
+	return &PrometheusWriter{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Family starts a metric family of the given type (counter, gauge, histogram, summary or untyped).
+func (p *PrometheusWriter) Family(name, kind, help string) {
+	p.write("# HELP " + name + " " + helpEscaper.Replace(help) + "\n# TYPE " + name + " " + kind + "\n")
+}
+
+// Sample writes a single sample. Labels are passed as name, value pairs.
+func (p *PrometheusWriter) Sample(name string, value float64, labels ...string) {
+	var b strings.Builder
+
+	b.WriteString(name)
+
+	if len(labels) != 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i != 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i])
+			b.WriteString(`="`)
+			b.WriteString(labelEscaper.Replace(labels[i+1]))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+
+	p.write(b.String())
+}
+
+// Flush writes out any buffered data and returns the first error encountered while writing, if any.
+func (p *PrometheusWriter) Flush() error {
+	if p.err == nil {
+		p.err = p.w.Flush()
+	}
+
+	return p.err
+}
+
+func (p *PrometheusWriter) write(s string) {
+	if p.err == nil {
+		_, p.err = p.w.WriteString(s)
+	}
+}
@@ -8,20 +8,46 @@ import (
 )
 
 type RequestsAggregator struct {
-	// open   *uint32
+	open   map[string]*inFlightGauge
 	second *uint32
 	minute *uint32
 	hour   *uint32
 	total  *uint32
+	// Requests and in-flight peaks per interface, per second and per minute.
+	history *Series
+}
+
+// inFlightGauge tracks the number of requests being handled by an interface, along with the
+// high-water marks within the current second and minute.
+type inFlightGauge struct {
+	open       *int32
+	peakSecond *int32
+	peakMinute *int32
 }
 
 func NewRequestsAggregator() *RequestsAggregator {
 	return &RequestsAggregator{
-		// open:   new(uint32),
+		open: map[string]*inFlightGauge{
+			InterfaceGrpc: newInFlightGauge(),
+			InterfaceHttp: newInFlightGauge(),
+		},
 		second: new(uint32),
 		minute: new(uint32),
 		hour:   new(uint32),
 		total:  new(uint32),
+		history: NewSeries(
+			SeriesField{Name: "requests", Kind: FieldCounter},
+			SeriesField{Name: "in_flight_peak_" + InterfaceGrpc, Kind: FieldPeak},
+			SeriesField{Name: "in_flight_peak_" + InterfaceHttp, Kind: FieldPeak},
+		),
+	}
+}
+
+func newInFlightGauge() *inFlightGauge {
+	return &inFlightGauge{
+		open:       new(int32),
+		peakSecond: new(int32),
+		peakMinute: new(int32),
 	}
 }
 
@@ -31,44 +57,110 @@ func (a *RequestsAggregator) Bootstrap(ticks server.TickManager) {
 	ticks.OnTickHour(a.onTickHour)
 }
 
-func (a *RequestsAggregator) Begin() {
-	// atomic.AddUint32(a.open, 1)
+// Begin counts a request to the given interface and marks it as in-flight until the matching End.
+func (a *RequestsAggregator) Begin(iface string) {
+	if gauge := a.open[iface]; gauge != nil {
+		open := atomic.AddInt32(gauge.open, 1)
+		raise(gauge.peakSecond, open)
+		raise(gauge.peakMinute, open)
+	}
+
 	atomic.AddUint32(a.second, 1)
 	atomic.AddUint32(a.minute, 1)
 	atomic.AddUint32(a.hour, 1)
 	atomic.AddUint32(a.total, 1)
 }
 
-func (a *RequestsAggregator) End() {
-	// atomic.AddUint32(a.open, ^uint32(0))
+func (a *RequestsAggregator) End(iface string) {
+	if gauge := a.open[iface]; gauge != nil {
+		atomic.AddInt32(gauge.open, -1)
+	}
+}
+
+// raise sets the high-water mark to v, unless it's already higher.
+func raise(peak *int32, v int32) {
+	for {
+		current := atomic.LoadInt32(peak)
+		if v <= current || atomic.CompareAndSwapInt32(peak, current, v) {
+			return
+		}
+	}
 }
 
 func (a *RequestsAggregator) onTickSecond(tick time.Time) {
-	atomic.StoreUint32(a.second, 0)
+	requests := atomic.SwapUint32(a.second, 0)
+
+	// New windows start at whatever is still in flight.
+	var peaks [2]int32
+	for i, iface := range []string{InterfaceGrpc, InterfaceHttp} {
+		gauge := a.open[iface]
+		peaks[i] = atomic.SwapInt32(gauge.peakSecond, atomic.LoadInt32(gauge.open))
+	}
+
+	a.history.Record(tick.Add(-time.Second), float64(requests), float64(peaks[0]), float64(peaks[1]))
 }
 
 func (a *RequestsAggregator) onTickMinute(tick time.Time) {
 	atomic.StoreUint32(a.minute, 0)
+
+	for _, gauge := range a.open {
+		atomic.StoreInt32(gauge.peakMinute, atomic.LoadInt32(gauge.open))
+	}
 }
 
 func (a *RequestsAggregator) onTickHour(tick time.Time) {
 	atomic.StoreUint32(a.hour, 0)
 }
 
+// History returns the per second or per minute history of requests within the given time range.
+func (a *RequestsAggregator) History(from, to time.Time, resolution Resolution) *SeriesSnapshot {
+	return a.history.Query(from, to, resolution)
+}
+
+type InFlightSnapshot struct {
+	Open       int32 `json:"open"`
+	PeakSecond int32 `json:"peakSecond"`
+	PeakMinute int32 `json:"peakMinute"`
+}
+
 type RequestsSnapshot struct {
-	// Open   uint32 `json:"open"`
-	Second uint32 `json:"second"`
-	Minute uint32 `json:"minute"`
-	Hour   uint32 `json:"hour"`
-	Total  uint32 `json:"total"`
+	Open   map[string]*InFlightSnapshot `json:"open"`
+	Second uint32                       `json:"second"`
+	Minute uint32                       `json:"minute"`
+	Hour   uint32                       `json:"hour"`
+	Total  uint32                       `json:"total"`
 }
 
 func (a *RequestsAggregator) Collect() *RequestsSnapshot {
+	open := make(map[string]*InFlightSnapshot, len(a.open))
+	for iface, gauge := range a.open {
+		open[iface] = &InFlightSnapshot{
+			Open:       atomic.LoadInt32(gauge.open),
+			PeakSecond: atomic.LoadInt32(gauge.peakSecond),
+			PeakMinute: atomic.LoadInt32(gauge.peakMinute),
+		}
+	}
+
 	return &RequestsSnapshot{
-		// Open:   atomic.LoadUint32(a.open),
+		Open:   open,
 		Second: atomic.LoadUint32(a.second),
 		Minute: atomic.LoadUint32(a.minute),
 		Hour:   atomic.LoadUint32(a.hour),
 		Total:  atomic.LoadUint32(a.total),
 	}
 }
+
+func (s *RequestsSnapshot) writePrometheus(w *PrometheusWriter) {
+	w.Family("glitchd_requests_total", "counter", "Number of requests handled.")
+	w.Sample("glitchd_requests_total", float64(s.Total))
+
+	w.Family("glitchd_requests_in_flight", "gauge", "Number of requests currently being handled per interface.")
+	for _, iface := range []string{InterfaceGrpc, InterfaceHttp} {
+		w.Sample("glitchd_requests_in_flight", float64(s.Open[iface].Open), "interface", iface)
+	}
+
+	w.Family("glitchd_requests_in_flight_peak", "gauge", "Highest number of requests in flight per interface within the current minute.")
+	for _, iface := range []string{InterfaceGrpc, InterfaceHttp} {
+		w.Sample("glitchd_requests_in_flight_peak", float64(s.Open[iface].PeakMinute), "interface", iface)
+	}
+}
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Retention of the history kept by a Series - per second buckets for the last hour and per minute
+// buckets for the last day.
+const (
+	SERIES_SECONDS = 3600
+	SERIES_MINUTES = 1440
+)
+
+var ErrInvalidHistoryQuery = errors.New("invalid history query")
+
+// FieldKind determines how the per second values of a field get rolled up into per minute buckets.
+type FieldKind uint8
+
+const (
+	// Counters get summed up.
+	FieldCounter FieldKind = iota
+	// Gauges retain their last value.
+	FieldGauge
+	// Peaks retain their highest value.
+	FieldPeak
+)
+
+type SeriesField struct {
+	Name string
+	Kind FieldKind
+}
+
+type Resolution string
+
+const (
+	ResolutionSecond Resolution = "second"
+	ResolutionMinute Resolution = "minute"
+)
+
+// Series is a rolling time-series of a fixed set of fields, backed by ring buffers. Buckets are
+// identified by the Unix time (in seconds) of their start. Memory is allocated upfront - 8 bytes
+// per field per bucket, so roughly 40KiB per field.
+type Series struct {
+	mu      sync.Mutex
+	fields  []SeriesField
+	seconds seriesRing
+	minutes seriesRing
+}
+
+type seriesRing struct {
+	times  []int64
+	values []float64 // len(times) * len(fields), bucket after bucket.
+}
+
+func newSeriesRing(length, fields int) seriesRing {
+	return seriesRing{
+		times:  make([]int64, length),
+		values: make([]float64, length*fields),
+	}
+}
+
+// bucket returns the values of the bucket starting at t (in units of step seconds), along with
+// whether it's been recorded already.
+func (ring *seriesRing) bucket(t int64, step int64, fields int) ([]float64, bool) {
+	i := int((t / step) % int64(len(ring.times)))
+	return ring.values[i*fields : (i+1)*fields], ring.times[i] == t
+}
+
+func NewSeries(fields ...SeriesField) *Series {
+	return &Series{
+		fields:  fields,
+		seconds: newSeriesRing(SERIES_SECONDS, len(fields)),
+		minutes: newSeriesRing(SERIES_MINUTES, len(fields)),
+	}
+}
+
+// Record records the values of the fields (in the order the Series has been constructed with)
+// for the second starting at t.
+func (s *Series) Record(t time.Time, values ...float64) {
+	var (
+		n   = len(s.fields)
+		sec = t.Unix()
+		min = sec - sec%60
+	)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, _ := s.seconds.bucket(sec, 1, n)
+	copy(bucket, values)
+	s.seconds.times[int(sec%SERIES_SECONDS)] = sec
+
+	bucket, ok := s.minutes.bucket(min, 60, n)
+	if !ok {
+		copy(bucket, values)
+		s.minutes.times[int((min/60)%SERIES_MINUTES)] = min
+		return
+	}
+
+	for i, field := range s.fields {
+		switch field.Kind {
+		case FieldCounter:
+			bucket[i] += values[i]
+		case FieldGauge:
+			bucket[i] = values[i]
+		case FieldPeak:
+			if values[i] > bucket[i] {
+				bucket[i] = values[i]
+			}
+		}
+	}
+}
+
+type SeriesPoint struct {
+	Time   int64     `json:"t"`
+	Values []float64 `json:"v"`
+}
+
+type SeriesSnapshot struct {
+	Fields     []string      `json:"fields"`
+	Resolution Resolution    `json:"resolution"`
+	Points     []SeriesPoint `json:"points"`
+}
+
+// Query returns the recorded buckets starting within [from, to] at the given resolution, in order.
+// Buckets which have not been recorded (or have been overwritten already) are omitted.
+func (s *Series) Query(from, to time.Time, resolution Resolution) *SeriesSnapshot {
+	var (
+		n     = len(s.fields)
+		ring  = &s.seconds
+		step  = int64(1)
+		start = from.Unix()
+		end   = to.Unix()
+	)
+
+	if resolution == ResolutionMinute {
+		ring = &s.minutes
+		step = 60
+	}
+
+	// Align to bucket boundaries and skip whatever can't be retained anyways.
+	if start%step != 0 {
+		start += step - start%step
+	}
+
+	if oldest := end - end%step - step*int64(len(ring.times)-1); start < oldest {
+		start = oldest
+	}
+
+	snapshot := &SeriesSnapshot{
+		Fields:     make([]string, n),
+		Resolution: resolution,
+		Points:     []SeriesPoint{},
+	}
+
+	for i, field := range s.fields {
+		snapshot.Fields[i] = field.Name
+	}
+
+	s.mu.Lock()
+	for t := start; t <= end; t += step {
+		if bucket, ok := ring.bucket(t, step, n); ok {
+			snapshot.Points = append(snapshot.Points, SeriesPoint{
+				Time:   t,
+				Values: append([]float64(nil), bucket...),
+			})
+		}
+	}
+	s.mu.Unlock()
+
+	return snapshot
+}
+
+// ParseHistoryQuery parses the parameters of a history query - from and to as Unix times in
+// seconds, and the resolution. By default the query spans the last hour up to now, at per second
+// resolution if the range starts within the last hour and per minute resolution otherwise.
+func ParseHistoryQuery(from, to, resolution string, now time.Time) (time.Time, time.Time, Resolution, error) {
+	var (
+		start = now.Add(-time.Hour)
+		end   = now
+	)
+
+	if to != "" {
+		v, err := strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return start, end, "", ErrInvalidHistoryQuery
+		}
+		end = time.Unix(v, 0)
+		start = end.Add(-time.Hour)
+	}
+
+	if from != "" {
+		v, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return start, end, "", ErrInvalidHistoryQuery
+		}
+		start = time.Unix(v, 0)
+	}
+
+	if start.After(end) {
+		return start, end, "", ErrInvalidHistoryQuery
+	}
+
+	switch Resolution(resolution) {
+	case ResolutionSecond, ResolutionMinute:
+		return start, end, Resolution(resolution), nil
+	case "":
+		if now.Sub(start) <= time.Hour {
+			return start, end, ResolutionSecond, nil
+		}
+		return start, end, ResolutionMinute, nil
+	}
+
+	return start, end, "", ErrInvalidHistoryQuery
+}
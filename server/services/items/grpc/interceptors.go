@@ -2,6 +2,8 @@ package grpc
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -17,25 +19,94 @@ const storeCtxKey ctxKey = 0
 
 func UnaryStoreExtractor(stores *types.StoreRepository) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		var (
-			md metadata.MD
-			ok bool
-		)
+		store, err := storeFromMetadata(ctx, stores)
+		if err != nil {
+			return nil, err
+		}
 
-		md, ok = metadata.FromIncomingContext(ctx)
+		return handler(context.WithValue(ctx, storeCtxKey, store), req)
+	}
+}
 
-		// Expecting metadata to be always present and at the very least contain exactly one token. No more, no less.
-		if !ok || len(md["token"]) != 1 || len(md["token"][0]) != types.TOKEN_LENGTH {
-			return nil, status.Errorf(codes.Unauthenticated, "Missing access token.")
+func StreamStoreExtractor(stores *types.StoreRepository) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		store, err := storeFromMetadata(ss.Context(), stores)
+		if err != nil {
+			return err
 		}
 
-		// Note: Returning 403 instead of 404 here because a Store must always be present for a valid token.
-		// No store mapped to the given token effectively means the token is invalid.
-		store := stores.Items[md["token"][0]]
-		if store == nil {
-			return nil, status.Errorf(codes.PermissionDenied, "Unknown access token.")
+		return handler(srv, &storeServerStream{
+			ServerStream: ss,
+			ctx:          context.WithValue(ss.Context(), storeCtxKey, store),
+		})
+	}
+}
+
+// storeServerStream carries the Store resolved by StreamStoreExtractor in its Context, since
+// streams - unlike unary calls - do not allow for passing a new Context down the chain.
+type storeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss *storeServerStream) Context() context.Context {
+	return ss.ctx
+}
+
+// UnaryRateLimiter rejects calls exceeding the rate limit of the Store. Must run after UnaryStoreExtractor.
+func UnaryRateLimiter() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if ok, wait := ctx.Value(storeCtxKey).(*types.Store).Allow(time.Now()); !ok {
+			grpc.SetTrailer(ctx, retryAfter(wait))
+			return nil, rateLimitError(wait)
 		}
 
-		return handler(context.WithValue(ctx, storeCtxKey, store), req)
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimiter rejects streams exceeding the rate limit of the Store. Must run after StreamStoreExtractor.
+// Only the opening of a stream counts towards the limit, not the messages sent over it.
+func StreamRateLimiter() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if ok, wait := ss.Context().Value(storeCtxKey).(*types.Store).Allow(time.Now()); !ok {
+			ss.SetTrailer(retryAfter(wait))
+			return rateLimitError(wait)
+		}
+
+		return handler(srv, ss)
 	}
 }
+
+// retryAfter returns the trailer telling clients after how many milliseconds to retry a throttled call.
+func retryAfter(wait time.Duration) metadata.MD {
+	return metadata.Pairs("retry-after-ms", strconv.FormatInt(int64(wait/time.Millisecond)+1, 10))
+}
+
+func rateLimitError(wait time.Duration) error {
+	return status.Errorf(codes.ResourceExhausted, "Rate limit exceeded. Retry in %s.", wait.Round(time.Millisecond))
+}
+
+// storeFromMetadata resolves the Store mapped to the access token passed in the incoming metadata.
+func storeFromMetadata(ctx context.Context, stores *types.StoreRepository) (*types.Store, error) {
+	var (
+		md metadata.MD
+		ok bool
+	)
+
+	md, ok = metadata.FromIncomingContext(ctx)
+
+	// Expecting metadata to be always present and at the very least contain exactly one token. No more, no less.
+	if !ok || len(md["token"]) != 1 || len(md["token"][0]) != types.TOKEN_LENGTH {
+		return nil, status.Errorf(codes.Unauthenticated, "Missing access token.")
+	}
+
+	// Note: Returning 403 instead of 404 here because a Store must always be present for a valid token.
+	// No store mapped to the given token effectively means the token is invalid.
+	store := stores.GetByToken(md["token"][0])
+	if store == nil {
+		return nil, status.Errorf(codes.PermissionDenied, "Unknown access token.")
+	}
+
+	return store, nil
+}
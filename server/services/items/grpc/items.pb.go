@@ -12,7 +12,20 @@ It has these top-level messages:
 	StoreGetRequest
 	StoreGetResponse
 	StorePutRequest
+	StorePutResponse
 	StoreDeleteRequest
+	StoreScanRequest
+	StoreScanResponse
+	StoreItem
+	StoreBatchGetRequest
+	StoreBatchGetResponse
+	StoreBatchPutRequest
+	StoreBatchPutResponse
+	StoreBatchDeleteRequest
+	StoreIncrementRequest
+	StoreIncrementResponse
+	StoreWatchRequest
+	StoreWatchEvent
 */
 package grpc
 
@@ -36,6 +49,27 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type StoreWatchEvent_Type int32
+
+const (
+	StoreWatchEvent_PUT    StoreWatchEvent_Type = 0
+	StoreWatchEvent_DELETE StoreWatchEvent_Type = 1
+)
+
+var StoreWatchEvent_Type_name = map[int32]string{
+	0: "PUT",
+	1: "DELETE",
+}
+var StoreWatchEvent_Type_value = map[string]int32{
+	"PUT":    0,
+	"DELETE": 1,
+}
+
+func (x StoreWatchEvent_Type) String() string {
+	return proto.EnumName(StoreWatchEvent_Type_name, int32(x))
+}
+func (StoreWatchEvent_Type) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{17, 0} }
+
 type Empty struct {
 }
 
@@ -61,7 +95,8 @@ func (m *StoreGetRequest) GetKey() string {
 }
 
 type StoreGetResponse struct {
-	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Value   []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Version uint64 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
 }
 
 func (m *StoreGetResponse) Reset()                    { *m = StoreGetResponse{} }
@@ -76,9 +111,19 @@ func (m *StoreGetResponse) GetValue() []byte {
 	return nil
 }
 
+func (m *StoreGetResponse) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 type StorePutRequest struct {
-	Key   string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
-	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Key             string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Value           []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	ExpectedVersion uint64 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion" json:"expected_version,omitempty"`
+	MustNotExist    bool   `protobuf:"varint,4,opt,name=must_not_exist,json=mustNotExist" json:"must_not_exist,omitempty"`
+	Ttl             uint32 `protobuf:"varint,5,opt,name=ttl" json:"ttl,omitempty"`
 }
 
 func (m *StorePutRequest) Reset()                    { *m = StorePutRequest{} }
@@ -100,14 +145,52 @@ func (m *StorePutRequest) GetValue() []byte {
 	return nil
 }
 
+func (m *StorePutRequest) GetExpectedVersion() uint64 {
+	if m != nil {
+		return m.ExpectedVersion
+	}
+	return 0
+}
+
+func (m *StorePutRequest) GetMustNotExist() bool {
+	if m != nil {
+		return m.MustNotExist
+	}
+	return false
+}
+
+func (m *StorePutRequest) GetTtl() uint32 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type StorePutResponse struct {
+	Version uint64 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *StorePutResponse) Reset()                    { *m = StorePutResponse{} }
+func (m *StorePutResponse) String() string            { return proto.CompactTextString(m) }
+func (*StorePutResponse) ProtoMessage()               {}
+func (*StorePutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *StorePutResponse) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 type StoreDeleteRequest struct {
-	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Key             string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	ExpectedVersion uint64 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion" json:"expected_version,omitempty"`
 }
 
 func (m *StoreDeleteRequest) Reset()                    { *m = StoreDeleteRequest{} }
 func (m *StoreDeleteRequest) String() string            { return proto.CompactTextString(m) }
 func (*StoreDeleteRequest) ProtoMessage()               {}
-func (*StoreDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*StoreDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 func (m *StoreDeleteRequest) GetKey() string {
 	if m != nil {
@@ -116,12 +199,377 @@ func (m *StoreDeleteRequest) GetKey() string {
 	return ""
 }
 
+func (m *StoreDeleteRequest) GetExpectedVersion() uint64 {
+	if m != nil {
+		return m.ExpectedVersion
+	}
+	return 0
+}
+
+type StoreScanRequest struct {
+	Prefix string `protobuf:"bytes,1,opt,name=prefix" json:"prefix,omitempty"`
+	Start  string `protobuf:"bytes,2,opt,name=start" json:"start,omitempty"`
+	End    string `protobuf:"bytes,3,opt,name=end" json:"end,omitempty"`
+	Cursor string `protobuf:"bytes,4,opt,name=cursor" json:"cursor,omitempty"`
+	Limit  uint32 `protobuf:"varint,5,opt,name=limit" json:"limit,omitempty"`
+	Values bool   `protobuf:"varint,6,opt,name=values" json:"values,omitempty"`
+}
+
+func (m *StoreScanRequest) Reset()                    { *m = StoreScanRequest{} }
+func (m *StoreScanRequest) String() string            { return proto.CompactTextString(m) }
+func (*StoreScanRequest) ProtoMessage()               {}
+func (*StoreScanRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *StoreScanRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *StoreScanRequest) GetStart() string {
+	if m != nil {
+		return m.Start
+	}
+	return ""
+}
+
+func (m *StoreScanRequest) GetEnd() string {
+	if m != nil {
+		return m.End
+	}
+	return ""
+}
+
+func (m *StoreScanRequest) GetCursor() string {
+	if m != nil {
+		return m.Cursor
+	}
+	return ""
+}
+
+func (m *StoreScanRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *StoreScanRequest) GetValues() bool {
+	if m != nil {
+		return m.Values
+	}
+	return false
+}
+
+type StoreScanResponse struct {
+	Items  []*StoreItem `protobuf:"bytes,1,rep,name=items" json:"items,omitempty"`
+	Cursor string       `protobuf:"bytes,2,opt,name=cursor" json:"cursor,omitempty"`
+}
+
+func (m *StoreScanResponse) Reset()                    { *m = StoreScanResponse{} }
+func (m *StoreScanResponse) String() string            { return proto.CompactTextString(m) }
+func (*StoreScanResponse) ProtoMessage()               {}
+func (*StoreScanResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *StoreScanResponse) GetItems() []*StoreItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+func (m *StoreScanResponse) GetCursor() string {
+	if m != nil {
+		return m.Cursor
+	}
+	return ""
+}
+
+type StoreItem struct {
+	Key     string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Value   []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Version uint64 `protobuf:"varint,3,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *StoreItem) Reset()                    { *m = StoreItem{} }
+func (m *StoreItem) String() string            { return proto.CompactTextString(m) }
+func (*StoreItem) ProtoMessage()               {}
+func (*StoreItem) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *StoreItem) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *StoreItem) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *StoreItem) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type StoreBatchGetRequest struct {
+	Keys []string `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *StoreBatchGetRequest) Reset()                    { *m = StoreBatchGetRequest{} }
+func (m *StoreBatchGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*StoreBatchGetRequest) ProtoMessage()               {}
+func (*StoreBatchGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *StoreBatchGetRequest) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type StoreBatchGetResponse struct {
+	Items []*StoreItem `protobuf:"bytes,1,rep,name=items" json:"items,omitempty"`
+}
+
+func (m *StoreBatchGetResponse) Reset()                    { *m = StoreBatchGetResponse{} }
+func (m *StoreBatchGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*StoreBatchGetResponse) ProtoMessage()               {}
+func (*StoreBatchGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *StoreBatchGetResponse) GetItems() []*StoreItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+type StoreBatchPutRequest struct {
+	Items []*StoreItem `protobuf:"bytes,1,rep,name=items" json:"items,omitempty"`
+}
+
+func (m *StoreBatchPutRequest) Reset()                    { *m = StoreBatchPutRequest{} }
+func (m *StoreBatchPutRequest) String() string            { return proto.CompactTextString(m) }
+func (*StoreBatchPutRequest) ProtoMessage()               {}
+func (*StoreBatchPutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *StoreBatchPutRequest) GetItems() []*StoreItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+type StoreBatchPutResponse struct {
+	Versions []uint64 `protobuf:"varint,1,rep,packed,name=versions" json:"versions,omitempty"`
+}
+
+func (m *StoreBatchPutResponse) Reset()                    { *m = StoreBatchPutResponse{} }
+func (m *StoreBatchPutResponse) String() string            { return proto.CompactTextString(m) }
+func (*StoreBatchPutResponse) ProtoMessage()               {}
+func (*StoreBatchPutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *StoreBatchPutResponse) GetVersions() []uint64 {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type StoreBatchDeleteRequest struct {
+	Keys []string `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *StoreBatchDeleteRequest) Reset()                    { *m = StoreBatchDeleteRequest{} }
+func (m *StoreBatchDeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*StoreBatchDeleteRequest) ProtoMessage()               {}
+func (*StoreBatchDeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *StoreBatchDeleteRequest) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type StoreIncrementRequest struct {
+	Key    string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Delta  int64  `protobuf:"varint,2,opt,name=delta" json:"delta,omitempty"`
+	HasMin bool   `protobuf:"varint,3,opt,name=has_min,json=hasMin" json:"has_min,omitempty"`
+	Min    int64  `protobuf:"varint,4,opt,name=min" json:"min,omitempty"`
+	HasMax bool   `protobuf:"varint,5,opt,name=has_max,json=hasMax" json:"has_max,omitempty"`
+	Max    int64  `protobuf:"varint,6,opt,name=max" json:"max,omitempty"`
+}
+
+func (m *StoreIncrementRequest) Reset()                    { *m = StoreIncrementRequest{} }
+func (m *StoreIncrementRequest) String() string            { return proto.CompactTextString(m) }
+func (*StoreIncrementRequest) ProtoMessage()               {}
+func (*StoreIncrementRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *StoreIncrementRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *StoreIncrementRequest) GetDelta() int64 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+func (m *StoreIncrementRequest) GetHasMin() bool {
+	if m != nil {
+		return m.HasMin
+	}
+	return false
+}
+
+func (m *StoreIncrementRequest) GetMin() int64 {
+	if m != nil {
+		return m.Min
+	}
+	return 0
+}
+
+func (m *StoreIncrementRequest) GetHasMax() bool {
+	if m != nil {
+		return m.HasMax
+	}
+	return false
+}
+
+func (m *StoreIncrementRequest) GetMax() int64 {
+	if m != nil {
+		return m.Max
+	}
+	return 0
+}
+
+type StoreIncrementResponse struct {
+	Value   int64  `protobuf:"varint,1,opt,name=value" json:"value,omitempty"`
+	Version uint64 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *StoreIncrementResponse) Reset()                    { *m = StoreIncrementResponse{} }
+func (m *StoreIncrementResponse) String() string            { return proto.CompactTextString(m) }
+func (*StoreIncrementResponse) ProtoMessage()               {}
+func (*StoreIncrementResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *StoreIncrementResponse) GetValue() int64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *StoreIncrementResponse) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type StoreWatchRequest struct {
+	Key           string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Prefix        bool   `protobuf:"varint,2,opt,name=prefix" json:"prefix,omitempty"`
+	AfterRevision uint64 `protobuf:"varint,3,opt,name=after_revision,json=afterRevision" json:"after_revision,omitempty"`
+}
+
+func (m *StoreWatchRequest) Reset()                    { *m = StoreWatchRequest{} }
+func (m *StoreWatchRequest) String() string            { return proto.CompactTextString(m) }
+func (*StoreWatchRequest) ProtoMessage()               {}
+func (*StoreWatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *StoreWatchRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *StoreWatchRequest) GetPrefix() bool {
+	if m != nil {
+		return m.Prefix
+	}
+	return false
+}
+
+func (m *StoreWatchRequest) GetAfterRevision() uint64 {
+	if m != nil {
+		return m.AfterRevision
+	}
+	return 0
+}
+
+type StoreWatchEvent struct {
+	Type     StoreWatchEvent_Type `protobuf:"varint,1,opt,name=type,enum=glitchd.items.StoreWatchEvent.Type" json:"type,omitempty"`
+	Key      string               `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	Value    []byte               `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Revision uint64               `protobuf:"varint,4,opt,name=revision" json:"revision,omitempty"`
+}
+
+func (m *StoreWatchEvent) Reset()                    { *m = StoreWatchEvent{} }
+func (m *StoreWatchEvent) String() string            { return proto.CompactTextString(m) }
+func (*StoreWatchEvent) ProtoMessage()               {}
+func (*StoreWatchEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *StoreWatchEvent) GetType() StoreWatchEvent_Type {
+	if m != nil {
+		return m.Type
+	}
+	return StoreWatchEvent_PUT
+}
+
+func (m *StoreWatchEvent) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *StoreWatchEvent) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *StoreWatchEvent) GetRevision() uint64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Empty)(nil), "glitchd.items.Empty")
 	proto.RegisterType((*StoreGetRequest)(nil), "glitchd.items.StoreGetRequest")
 	proto.RegisterType((*StoreGetResponse)(nil), "glitchd.items.StoreGetResponse")
 	proto.RegisterType((*StorePutRequest)(nil), "glitchd.items.StorePutRequest")
+	proto.RegisterType((*StorePutResponse)(nil), "glitchd.items.StorePutResponse")
 	proto.RegisterType((*StoreDeleteRequest)(nil), "glitchd.items.StoreDeleteRequest")
+	proto.RegisterType((*StoreScanRequest)(nil), "glitchd.items.StoreScanRequest")
+	proto.RegisterType((*StoreScanResponse)(nil), "glitchd.items.StoreScanResponse")
+	proto.RegisterType((*StoreItem)(nil), "glitchd.items.StoreItem")
+	proto.RegisterType((*StoreBatchGetRequest)(nil), "glitchd.items.StoreBatchGetRequest")
+	proto.RegisterType((*StoreBatchGetResponse)(nil), "glitchd.items.StoreBatchGetResponse")
+	proto.RegisterType((*StoreBatchPutRequest)(nil), "glitchd.items.StoreBatchPutRequest")
+	proto.RegisterType((*StoreBatchPutResponse)(nil), "glitchd.items.StoreBatchPutResponse")
+	proto.RegisterType((*StoreBatchDeleteRequest)(nil), "glitchd.items.StoreBatchDeleteRequest")
+	proto.RegisterType((*StoreIncrementRequest)(nil), "glitchd.items.StoreIncrementRequest")
+	proto.RegisterType((*StoreIncrementResponse)(nil), "glitchd.items.StoreIncrementResponse")
+	proto.RegisterType((*StoreWatchRequest)(nil), "glitchd.items.StoreWatchRequest")
+	proto.RegisterType((*StoreWatchEvent)(nil), "glitchd.items.StoreWatchEvent")
+	proto.RegisterEnum("glitchd.items.StoreWatchEvent.Type", StoreWatchEvent_Type_name, StoreWatchEvent_Type_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -136,8 +584,14 @@ const _ = grpc1.SupportPackageIsVersion4
 
 type StoreClient interface {
 	Get(ctx context.Context, in *StoreGetRequest, opts ...grpc1.CallOption) (*StoreGetResponse, error)
-	Put(ctx context.Context, in *StorePutRequest, opts ...grpc1.CallOption) (*Empty, error)
+	Put(ctx context.Context, in *StorePutRequest, opts ...grpc1.CallOption) (*StorePutResponse, error)
 	Delete(ctx context.Context, in *StoreDeleteRequest, opts ...grpc1.CallOption) (*Empty, error)
+	Scan(ctx context.Context, in *StoreScanRequest, opts ...grpc1.CallOption) (*StoreScanResponse, error)
+	BatchGet(ctx context.Context, in *StoreBatchGetRequest, opts ...grpc1.CallOption) (*StoreBatchGetResponse, error)
+	BatchPut(ctx context.Context, in *StoreBatchPutRequest, opts ...grpc1.CallOption) (*StoreBatchPutResponse, error)
+	BatchDelete(ctx context.Context, in *StoreBatchDeleteRequest, opts ...grpc1.CallOption) (*Empty, error)
+	Increment(ctx context.Context, in *StoreIncrementRequest, opts ...grpc1.CallOption) (*StoreIncrementResponse, error)
+	Watch(ctx context.Context, in *StoreWatchRequest, opts ...grpc1.CallOption) (Store_WatchClient, error)
 }
 
 type storeClient struct {
@@ -157,8 +611,8 @@ func (c *storeClient) Get(ctx context.Context, in *StoreGetRequest, opts ...grpc
 	return out, nil
 }
 
-func (c *storeClient) Put(ctx context.Context, in *StorePutRequest, opts ...grpc1.CallOption) (*Empty, error) {
-	out := new(Empty)
+func (c *storeClient) Put(ctx context.Context, in *StorePutRequest, opts ...grpc1.CallOption) (*StorePutResponse, error) {
+	out := new(StorePutResponse)
 	err := grpc1.Invoke(ctx, "/glitchd.items.Store/Put", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
@@ -175,12 +629,95 @@ func (c *storeClient) Delete(ctx context.Context, in *StoreDeleteRequest, opts .
 	return out, nil
 }
 
+func (c *storeClient) Scan(ctx context.Context, in *StoreScanRequest, opts ...grpc1.CallOption) (*StoreScanResponse, error) {
+	out := new(StoreScanResponse)
+	err := grpc1.Invoke(ctx, "/glitchd.items.Store/Scan", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeClient) BatchGet(ctx context.Context, in *StoreBatchGetRequest, opts ...grpc1.CallOption) (*StoreBatchGetResponse, error) {
+	out := new(StoreBatchGetResponse)
+	err := grpc1.Invoke(ctx, "/glitchd.items.Store/BatchGet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeClient) BatchPut(ctx context.Context, in *StoreBatchPutRequest, opts ...grpc1.CallOption) (*StoreBatchPutResponse, error) {
+	out := new(StoreBatchPutResponse)
+	err := grpc1.Invoke(ctx, "/glitchd.items.Store/BatchPut", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeClient) BatchDelete(ctx context.Context, in *StoreBatchDeleteRequest, opts ...grpc1.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc1.Invoke(ctx, "/glitchd.items.Store/BatchDelete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeClient) Increment(ctx context.Context, in *StoreIncrementRequest, opts ...grpc1.CallOption) (*StoreIncrementResponse, error) {
+	out := new(StoreIncrementResponse)
+	err := grpc1.Invoke(ctx, "/glitchd.items.Store/Increment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeClient) Watch(ctx context.Context, in *StoreWatchRequest, opts ...grpc1.CallOption) (Store_WatchClient, error) {
+	stream, err := grpc1.NewClientStream(ctx, &_Store_serviceDesc.Streams[0], c.cc, "/glitchd.items.Store/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storeWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Store_WatchClient interface {
+	Recv() (*StoreWatchEvent, error)
+	grpc1.ClientStream
+}
+
+type storeWatchClient struct {
+	grpc1.ClientStream
+}
+
+func (x *storeWatchClient) Recv() (*StoreWatchEvent, error) {
+	m := new(StoreWatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for Store service
 
 type StoreServer interface {
 	Get(context.Context, *StoreGetRequest) (*StoreGetResponse, error)
-	Put(context.Context, *StorePutRequest) (*Empty, error)
+	Put(context.Context, *StorePutRequest) (*StorePutResponse, error)
 	Delete(context.Context, *StoreDeleteRequest) (*Empty, error)
+	Scan(context.Context, *StoreScanRequest) (*StoreScanResponse, error)
+	BatchGet(context.Context, *StoreBatchGetRequest) (*StoreBatchGetResponse, error)
+	BatchPut(context.Context, *StoreBatchPutRequest) (*StoreBatchPutResponse, error)
+	BatchDelete(context.Context, *StoreBatchDeleteRequest) (*Empty, error)
+	Increment(context.Context, *StoreIncrementRequest) (*StoreIncrementResponse, error)
+	Watch(*StoreWatchRequest, Store_WatchServer) error
 }
 
 func RegisterStoreServer(s *grpc1.Server, srv StoreServer) {
@@ -241,6 +778,117 @@ func _Store_Delete_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Store_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc1.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServer).Scan(ctx, in)
+	}
+	info := &grpc1.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/glitchd.items.Store/Scan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServer).Scan(ctx, req.(*StoreScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Store_BatchGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc1.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreBatchGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServer).BatchGet(ctx, in)
+	}
+	info := &grpc1.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/glitchd.items.Store/BatchGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServer).BatchGet(ctx, req.(*StoreBatchGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Store_BatchPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc1.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreBatchPutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServer).BatchPut(ctx, in)
+	}
+	info := &grpc1.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/glitchd.items.Store/BatchPut",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServer).BatchPut(ctx, req.(*StoreBatchPutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Store_BatchDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc1.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreBatchDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServer).BatchDelete(ctx, in)
+	}
+	info := &grpc1.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/glitchd.items.Store/BatchDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServer).BatchDelete(ctx, req.(*StoreBatchDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Store_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc1.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreIncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServer).Increment(ctx, in)
+	}
+	info := &grpc1.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/glitchd.items.Store/Increment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServer).Increment(ctx, req.(*StoreIncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Store_Watch_Handler(srv interface{}, stream grpc1.ServerStream) error {
+	m := new(StoreWatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StoreServer).Watch(m, &storeWatchServer{stream})
+}
+
+type Store_WatchServer interface {
+	Send(*StoreWatchEvent) error
+	grpc1.ServerStream
+}
+
+type storeWatchServer struct {
+	grpc1.ServerStream
+}
+
+func (x *storeWatchServer) Send(m *StoreWatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Store_serviceDesc = grpc1.ServiceDesc{
 	ServiceName: "glitchd.items.Store",
 	HandlerType: (*StoreServer)(nil),
@@ -257,30 +905,91 @@ var _Store_serviceDesc = grpc1.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _Store_Delete_Handler,
 		},
+		{
+			MethodName: "Scan",
+			Handler:    _Store_Scan_Handler,
+		},
+		{
+			MethodName: "BatchGet",
+			Handler:    _Store_BatchGet_Handler,
+		},
+		{
+			MethodName: "BatchPut",
+			Handler:    _Store_BatchPut_Handler,
+		},
+		{
+			MethodName: "BatchDelete",
+			Handler:    _Store_BatchDelete_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _Store_Increment_Handler,
+		},
+	},
+	Streams: []grpc1.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Store_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc1.StreamDesc{},
 	Metadata: "items.proto",
 }
 
 func init() { proto.RegisterFile("items.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 264 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0xcf, 0x4b, 0xc3, 0x30,
-	0x14, 0xc7, 0x57, 0x4b, 0x27, 0x3e, 0x15, 0xc7, 0x63, 0x07, 0xd9, 0xc1, 0x1f, 0x11, 0x64, 0xa7,
-	0x04, 0xdd, 0x45, 0x05, 0x2f, 0xfe, 0x60, 0x1e, 0x47, 0xbd, 0x79, 0xdb, 0xea, 0xa3, 0xab, 0x6b,
-	0x4d, 0x4d, 0x5e, 0x06, 0xfb, 0x43, 0xfd, 0x7f, 0xc4, 0x74, 0x50, 0x37, 0xcc, 0xa9, 0x4d, 0xde,
-	0xf7, 0xfb, 0x21, 0x9f, 0x04, 0xf6, 0x0b, 0xa6, 0xca, 0xca, 0xda, 0x68, 0xd6, 0x78, 0x98, 0x97,
-	0x05, 0x67, 0xf3, 0x77, 0xe9, 0x37, 0xc5, 0x2e, 0x24, 0xcf, 0x55, 0xcd, 0x2b, 0x71, 0x01, 0x47,
-	0xaf, 0xac, 0x0d, 0x8d, 0x89, 0x53, 0xfa, 0x72, 0x64, 0x19, 0x7b, 0x10, 0x2f, 0x68, 0x75, 0x1c,
-	0x9d, 0x45, 0xc3, 0xbd, 0xf4, 0xf7, 0x57, 0x0c, 0xa1, 0xd7, 0x86, 0x6c, 0xad, 0x3f, 0x2d, 0x61,
-	0x1f, 0x92, 0xe5, 0xb4, 0x74, 0xe4, 0x73, 0x07, 0x69, 0xb3, 0x10, 0xb7, 0x6b, 0xdc, 0xc4, 0x85,
-	0x71, 0x6d, 0x75, 0xe7, 0x6f, 0xf5, 0x12, 0xd0, 0x57, 0x9f, 0xa8, 0x24, 0xa6, 0x60, 0xfb, 0xfa,
-	0x3b, 0x82, 0xc4, 0x07, 0xf1, 0x05, 0xe2, 0x31, 0x31, 0x9e, 0xc8, 0x0d, 0x37, 0xb9, 0xe5, 0x33,
-	0x38, 0x0d, 0xce, 0x1b, 0x15, 0xd1, 0xc1, 0x7b, 0x88, 0x27, 0x2e, 0x40, 0x6a, 0x55, 0x06, 0xfd,
-	0xad, 0x79, 0x73, 0x85, 0x1d, 0x7c, 0x84, 0x6e, 0x73, 0x6a, 0x3c, 0xff, 0x8f, 0xb0, 0x61, 0x14,
-	0x82, 0x3c, 0xdc, 0xbd, 0xdd, 0xe4, 0x05, 0xcf, 0xdd, 0x4c, 0x66, 0xba, 0x52, 0x1f, 0xf6, 0x6a,
-	0xb4, 0xc8, 0xa7, 0x15, 0x59, 0xb5, 0x8e, 0x2b, 0x4b, 0x66, 0x49, 0xc6, 0x7f, 0x8a, 0x8c, 0xac,
-	0xf2, 0x75, 0x95, 0x9b, 0x3a, 0x9b, 0x75, 0xfd, 0x23, 0x8f, 0x7e, 0x02, 0x00, 0x00, 0xff, 0xff,
-	0x55, 0x2c, 0x98, 0xba, 0xf3, 0x01, 0x00, 0x00,
+	// 827 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0x5f, 0x8f, 0xdb, 0x44,
+	0x10, 0x8f, 0x63, 0x27, 0x97, 0xcc, 0xf5, 0xae, 0x61, 0x75, 0x6d, 0xad, 0x20, 0x95, 0xb0, 0x6d,
+	0x51, 0x40, 0x90, 0xc0, 0xdd, 0x03, 0x88, 0xc7, 0xa3, 0xe1, 0x5a, 0xc1, 0xa1, 0xb0, 0x3d, 0xa8,
+	0x54, 0x1e, 0x22, 0xd7, 0x99, 0x5e, 0xcc, 0xc5, 0x76, 0xf0, 0xae, 0xa3, 0xe4, 0x83, 0x20, 0xf1,
+	0xc0, 0x37, 0xe0, 0x13, 0xf1, 0x6d, 0xd0, 0xfe, 0x71, 0xec, 0x04, 0x3b, 0x77, 0xf0, 0x94, 0x9d,
+	0xcd, 0x6f, 0x7e, 0xf3, 0x9b, 0xd9, 0x99, 0x49, 0xe0, 0x30, 0x10, 0x18, 0xf2, 0xc1, 0x22, 0x89,
+	0x45, 0x4c, 0x8e, 0xae, 0xe7, 0x81, 0xf0, 0x67, 0xd3, 0x81, 0xba, 0xa4, 0x07, 0xd0, 0x18, 0x85,
+	0x0b, 0xb1, 0xa6, 0x4f, 0xe0, 0xfe, 0x2b, 0x11, 0x27, 0x78, 0x81, 0x82, 0xe1, 0x6f, 0x29, 0x72,
+	0x41, 0x3a, 0x60, 0xdf, 0xe0, 0xda, 0xb5, 0x7a, 0x56, 0xbf, 0xcd, 0xe4, 0x91, 0x9e, 0x43, 0x27,
+	0x07, 0xf1, 0x45, 0x1c, 0x71, 0x24, 0x27, 0xd0, 0x58, 0x7a, 0xf3, 0x14, 0x15, 0xee, 0x1e, 0xd3,
+	0x06, 0x71, 0xe1, 0x60, 0x89, 0x09, 0x0f, 0xe2, 0xc8, 0xad, 0xf7, 0xac, 0xbe, 0xc3, 0x32, 0x93,
+	0xfe, 0x69, 0x99, 0x48, 0xe3, 0xb4, 0x3a, 0x52, 0xce, 0x5a, 0x2f, 0xb2, 0x7e, 0x0c, 0x1d, 0x5c,
+	0x2d, 0xd0, 0x17, 0x38, 0x9d, 0x64, 0xf4, 0xb6, 0xa2, 0xbf, 0x9f, 0xdd, 0xff, 0xac, 0xaf, 0xc9,
+	0x53, 0x38, 0x0e, 0x53, 0x2e, 0x26, 0x51, 0x2c, 0x26, 0xb8, 0x0a, 0xb8, 0x70, 0x9d, 0x9e, 0xd5,
+	0x6f, 0xb1, 0x7b, 0xf2, 0xf6, 0x87, 0x58, 0x8c, 0x56, 0x81, 0x0e, 0x2c, 0xc4, 0xdc, 0x6d, 0xf4,
+	0xac, 0xfe, 0x11, 0x93, 0x47, 0xfa, 0x29, 0x74, 0x72, 0x75, 0x26, 0xc5, 0x42, 0x32, 0xd6, 0x76,
+	0x32, 0x3f, 0x02, 0x51, 0xe8, 0xe7, 0x38, 0x47, 0x81, 0xd5, 0xe9, 0x94, 0x09, 0xaf, 0x97, 0x0a,
+	0xa7, 0x7f, 0x58, 0x46, 0xc1, 0x2b, 0xdf, 0x8b, 0x32, 0xc6, 0x87, 0xd0, 0x5c, 0x24, 0xf8, 0x2e,
+	0x58, 0x19, 0x52, 0x63, 0xc9, 0x32, 0x71, 0xe1, 0x25, 0x42, 0x91, 0xb5, 0x99, 0x36, 0x64, 0x7c,
+	0x8c, 0xa6, 0xaa, 0x32, 0x6d, 0x26, 0x8f, 0xd2, 0xdf, 0x4f, 0x13, 0x1e, 0x27, 0xaa, 0x0a, 0x6d,
+	0x66, 0x2c, 0xe9, 0x3f, 0x0f, 0xc2, 0x40, 0x98, 0x0a, 0x68, 0x43, 0xa2, 0x55, 0xbd, 0xb9, 0xdb,
+	0x54, 0x35, 0x33, 0x16, 0xfd, 0x05, 0xde, 0x2b, 0x28, 0x33, 0xc5, 0x19, 0x40, 0x43, 0xb5, 0x92,
+	0x6b, 0xf5, 0xec, 0xfe, 0xe1, 0xa9, 0x3b, 0xd8, 0x6a, 0xb0, 0x81, 0x72, 0x78, 0x29, 0x30, 0x64,
+	0x1a, 0x56, 0x90, 0x52, 0x2f, 0x4a, 0xa1, 0x97, 0xd0, 0xde, 0x60, 0xef, 0xdc, 0x10, 0x85, 0x97,
+	0xb1, 0xb7, 0x5f, 0xe6, 0x13, 0x38, 0x51, 0x74, 0xe7, 0x9e, 0xf0, 0x67, 0x85, 0xa6, 0x26, 0xe0,
+	0xdc, 0xe0, 0x5a, 0xab, 0x6d, 0x33, 0x75, 0xa6, 0x17, 0xf0, 0x60, 0x07, 0xfb, 0xff, 0x72, 0xa3,
+	0xdf, 0x16, 0x83, 0x16, 0xfa, 0xfb, 0xbf, 0xf2, 0x9c, 0xc1, 0x83, 0x1d, 0x1e, 0x23, 0xa8, 0x0b,
+	0x2d, 0x93, 0xa0, 0xe6, 0x72, 0xd8, 0xc6, 0xa6, 0x9f, 0xc1, 0xa3, 0xdc, 0x69, 0xbb, 0x21, 0xcb,
+	0x92, 0xfe, 0xdd, 0x32, 0x41, 0x5e, 0x46, 0x7e, 0x82, 0x21, 0x46, 0xfb, 0xa7, 0x71, 0x8a, 0x73,
+	0xe1, 0xa9, 0xe2, 0xdb, 0x4c, 0x1b, 0xe4, 0x11, 0x1c, 0xcc, 0x3c, 0x3e, 0x09, 0x03, 0x5d, 0xfc,
+	0x16, 0x6b, 0xce, 0x3c, 0x7e, 0x19, 0x44, 0x92, 0x40, 0x5e, 0x3a, 0x0a, 0x2c, 0x8f, 0x1b, 0xa8,
+	0xb7, 0x72, 0x1b, 0x39, 0xd4, 0x5b, 0x29, 0xa8, 0xb7, 0x72, 0x9b, 0x06, 0xea, 0xad, 0xe8, 0x0b,
+	0x78, 0xb8, 0x2b, 0xab, 0x6c, 0xd3, 0xd8, 0xb7, 0x6f, 0x9a, 0xa9, 0x69, 0xd7, 0xd7, 0xb2, 0x20,
+	0xd5, 0xc9, 0xe5, 0xb3, 0x55, 0xd7, 0xd2, 0xb4, 0x45, 0x9e, 0xc1, 0xb1, 0xf7, 0x4e, 0x60, 0x32,
+	0x49, 0x70, 0x19, 0x14, 0x5a, 0xec, 0x48, 0xdd, 0x32, 0x73, 0x49, 0xff, 0xca, 0xf6, 0x99, 0x0a,
+	0x33, 0x5a, 0x62, 0x24, 0xc8, 0x97, 0xe0, 0x88, 0xf5, 0x42, 0x0b, 0x3d, 0x3e, 0x7d, 0x52, 0xf6,
+	0xdc, 0x39, 0x7a, 0x70, 0xb5, 0x5e, 0x20, 0x53, 0x0e, 0x99, 0xba, 0x7a, 0x49, 0xdf, 0xdb, 0xc5,
+	0xbe, 0xef, 0x42, 0x6b, 0xa3, 0xca, 0x51, 0xaa, 0x36, 0x36, 0x7d, 0x1f, 0x1c, 0xc9, 0x48, 0x0e,
+	0xc0, 0x1e, 0xff, 0x74, 0xd5, 0xa9, 0x11, 0x80, 0xe6, 0xf3, 0xd1, 0xf7, 0xa3, 0xab, 0x51, 0xc7,
+	0x3a, 0xfd, 0xbb, 0x01, 0x0d, 0x15, 0x9f, 0xbc, 0x00, 0xfb, 0x02, 0x05, 0x79, 0x5c, 0x26, 0x2e,
+	0x9f, 0x97, 0xee, 0x07, 0x95, 0xdf, 0xeb, 0x57, 0xa1, 0x35, 0xc9, 0x34, 0x4e, 0x2b, 0x98, 0xc6,
+	0xe9, 0x7e, 0xa6, 0x42, 0x73, 0xd3, 0x1a, 0xf9, 0x06, 0x9a, 0xba, 0x71, 0xc9, 0x87, 0x65, 0xe0,
+	0xad, 0xa6, 0xee, 0x9e, 0xec, 0x40, 0xf4, 0xef, 0x58, 0x8d, 0x7c, 0x07, 0x8e, 0x5c, 0x50, 0xa4,
+	0x34, 0x5e, 0x61, 0xa9, 0x76, 0x7b, 0xd5, 0x80, 0x8d, 0xa2, 0xd7, 0xd0, 0xca, 0xb6, 0x02, 0x29,
+	0x7d, 0xc7, 0x9d, 0xfd, 0xd2, 0x7d, 0xba, 0x1f, 0xf4, 0x2f, 0xe2, 0x71, 0xba, 0x8f, 0x78, 0x9c,
+	0xde, 0x81, 0x78, 0xbb, 0x86, 0x97, 0x70, 0x58, 0xd8, 0x00, 0xe4, 0xa3, 0x4a, 0xb7, 0xbb, 0x55,
+	0xf3, 0x0d, 0xb4, 0x37, 0x93, 0x48, 0x4a, 0x35, 0xec, 0xee, 0x8f, 0xee, 0xb3, 0x5b, 0x50, 0x05,
+	0xa9, 0x0d, 0x35, 0x06, 0xa4, 0x57, 0x39, 0x21, 0x19, 0xe7, 0xe3, 0xfd, 0x33, 0x44, 0x6b, 0x9f,
+	0x5b, 0xe7, 0x5f, 0xbf, 0xf9, 0xea, 0x3a, 0x10, 0xb3, 0xf4, 0xed, 0xc0, 0x8f, 0xc3, 0xe1, 0xaf,
+	0xfc, 0x8b, 0xb3, 0x9b, 0x6b, 0x2f, 0x44, 0x3e, 0x34, 0xae, 0x43, 0x8e, 0xc9, 0x12, 0x13, 0xf5,
+	0x11, 0xf8, 0xc8, 0x87, 0x8a, 0x6a, 0x78, 0x9d, 0x2c, 0xfc, 0xb7, 0x4d, 0xf5, 0xef, 0xe8, 0xec,
+	0x9f, 0x01, 0x00, 0x36, 0xc6, 0x22, 0xec, 0x2c, 0x09, 0x00, 0x00,
 }
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -22,42 +23,238 @@ type Service struct{}
 //
 //
 func (s *Service) Get(ctx context.Context, in *StoreGetRequest) (*StoreGetResponse, error) {
-	val, err := ctx.Value(storeCtxKey).(*types.Store).Get(in.Key)
+	val, version, err := ctx.Value(storeCtxKey).(*types.Store).Get(in.Key)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to retrieve the item.")
+		return nil, storeError(err, "Failed to retrieve the item.")
 	}
 
 	if val == nil {
 		return nil, status.Errorf(codes.NotFound, "No value found for the requested key.")
 	}
 
-	return &StoreGetResponse{Value: val}, nil
+	return &StoreGetResponse{Value: val, Version: version}, nil
 }
 
 //
 //
 //
-func (s *Service) Put(ctx context.Context, in *StorePutRequest) (*Empty, error) {
+func (s *Service) Put(ctx context.Context, in *StorePutRequest) (*StorePutResponse, error) {
 	// Theoretically a put with a nil slice is equal to deleting an item, but we do want to ensure
 	// the calls are precise in intent and separate in concerns.
 	if in.Value == nil {
 		return nil, status.Errorf(codes.InvalidArgument, "Cannot put empty values. Call delete instead if you intended to delete an item.")
 	}
 
-	if err := ctx.Value(storeCtxKey).(*types.Store).Put(in.Key, in.Value); err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to store the item.")
+	ttl := time.Duration(in.Ttl) * time.Second
+
+	version, err := ctx.Value(storeCtxKey).(*types.Store).Put(in.Key, in.Value, ttl, types.Precondition{
+		Version:      in.ExpectedVersion,
+		MustNotExist: in.MustNotExist,
+	})
+	if err != nil {
+		return nil, storeError(err, "Failed to store the item.")
 	}
 
-	return &Empty{}, nil
+	return &StorePutResponse{Version: version}, nil
 }
 
 //
 //
 //
 func (s *Service) Delete(ctx context.Context, in *StoreDeleteRequest) (*Empty, error) {
-	if err := ctx.Value(storeCtxKey).(*types.Store).Delete(in.Key); err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to delete the item.")
+	if err := ctx.Value(storeCtxKey).(*types.Store).Delete(in.Key, types.Precondition{Version: in.ExpectedVersion}); err != nil {
+		return nil, storeError(err, "Failed to delete the item.")
 	}
 
 	return &Empty{}, nil
 }
+
+//
+//
+//
+func (s *Service) Scan(ctx context.Context, in *StoreScanRequest) (*StoreScanResponse, error) {
+	opts := types.ScanOptions{
+		Prefix: in.Prefix,
+		Start:  in.Start,
+		End:    in.End,
+		Limit:  int(in.Limit),
+		Values: in.Values,
+	}
+
+	// The cursor only ever moves the walk forward - it must not widen the requested range.
+	if in.Cursor > opts.Start {
+		opts.Start = in.Cursor
+	}
+
+	items, next, err := ctx.Value(storeCtxKey).(*types.Store).Scan(opts)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to scan the items.")
+	}
+
+	res := &StoreScanResponse{
+		Items:  make([]*StoreItem, len(items)),
+		Cursor: next,
+	}
+
+	for i, item := range items {
+		res.Items[i] = &StoreItem{Key: item.Key, Value: item.Value, Version: item.Version}
+	}
+
+	return res, nil
+}
+
+//
+//
+//
+func (s *Service) BatchGet(ctx context.Context, in *StoreBatchGetRequest) (*StoreBatchGetResponse, error) {
+	items, err := ctx.Value(storeCtxKey).(*types.Store).BatchGet(in.Keys)
+	if err != nil {
+		return nil, storeError(err, "Failed to retrieve the items.")
+	}
+
+	res := &StoreBatchGetResponse{
+		Items: make([]*StoreItem, len(items)),
+	}
+
+	for i, item := range items {
+		res.Items[i] = &StoreItem{Key: item.Key, Value: item.Value, Version: item.Version}
+	}
+
+	return res, nil
+}
+
+//
+//
+//
+func (s *Service) BatchPut(ctx context.Context, in *StoreBatchPutRequest) (*StoreBatchPutResponse, error) {
+	items := make([]types.Item, len(in.Items))
+
+	for i, item := range in.Items {
+		// See the note in Put().
+		if item.Value == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Cannot put empty values. Call delete instead if you intended to delete an item.")
+		}
+
+		items[i] = types.Item{Key: item.Key, Value: item.Value, Version: item.Version}
+	}
+
+	versions, err := ctx.Value(storeCtxKey).(*types.Store).BatchPut(items)
+	if err != nil {
+		return nil, storeError(err, "Failed to store the items.")
+	}
+
+	return &StoreBatchPutResponse{Versions: versions}, nil
+}
+
+//
+//
+//
+func (s *Service) BatchDelete(ctx context.Context, in *StoreBatchDeleteRequest) (*Empty, error) {
+	if err := ctx.Value(storeCtxKey).(*types.Store).BatchDelete(in.Keys); err != nil {
+		return nil, storeError(err, "Failed to delete the items.")
+	}
+
+	return &Empty{}, nil
+}
+
+//
+//
+//
+func (s *Service) Increment(ctx context.Context, in *StoreIncrementRequest) (*StoreIncrementResponse, error) {
+	var bounds types.Bounds
+
+	if in.HasMin {
+		bounds.Min = &in.Min
+	}
+
+	if in.HasMax {
+		bounds.Max = &in.Max
+	}
+
+	value, version, err := ctx.Value(storeCtxKey).(*types.Store).Increment(in.Key, in.Delta, bounds)
+	if err != nil {
+		return nil, storeError(err, "Failed to increment the item.")
+	}
+
+	return &StoreIncrementResponse{Value: value, Version: version}, nil
+}
+
+//
+//
+//
+func (s *Service) Watch(in *StoreWatchRequest, stream Store_WatchServer) error {
+	watcher, err := stream.Context().Value(storeCtxKey).(*types.Store).Watch(in.Key, in.Prefix, in.AfterRevision)
+	if err != nil {
+		return watchError(err)
+	}
+	defer watcher.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+
+		case ev, ok := <-watcher.Events():
+			if !ok {
+				return watchError(watcher.Err())
+			}
+
+			res := &StoreWatchEvent{
+				Type:     StoreWatchEvent_PUT,
+				Key:      ev.Key,
+				Value:    ev.Value,
+				Revision: ev.Revision,
+			}
+
+			if ev.Type == types.EventDelete {
+				res.Type = StoreWatchEvent_DELETE
+			}
+
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchError maps the reasons a watch ends (or fails to start) onto their respective status codes.
+// A nil error means the watch ended normally.
+func watchError(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case types.ErrRevisionCompacted:
+		return status.Errorf(codes.OutOfRange, "Events after the requested revision are no longer retained. Re-read the items and watch without a revision.")
+	case types.ErrWatcherLagged:
+		return status.Errorf(codes.Aborted, "The watch fell too far behind. Resume from the last received revision.")
+	case types.ErrStoreDeleted:
+		return status.Errorf(codes.NotFound, "The store has been deleted.")
+	}
+
+	return status.Errorf(codes.Internal, "Failed to watch the items.")
+}
+
+// storeError maps errors returned by the Store onto their respective status codes. Errors
+// not known to be caused by the request itself are considered internal and masked by msg.
+func storeError(err error, msg string) error {
+	switch err {
+	case types.ErrInvalidKey:
+		return status.Errorf(codes.InvalidArgument, "Keys must be non-empty and at most %d bytes long.", types.KEY_SIZE_MAX)
+	case types.ErrItemTooLarge:
+		return status.Errorf(codes.ResourceExhausted, "Item exceeds the max size of %d bytes.", types.ITEM_SIZE_MAX)
+	case types.ErrQuotaExceeded:
+		return status.Errorf(codes.ResourceExhausted, "The write exceeds the quota of the store.")
+	case types.ErrPreconditionFailed:
+		return status.Errorf(codes.FailedPrecondition, "The item does not match the expected version or existence.")
+	case types.ErrNotAnInteger:
+		return status.Errorf(codes.FailedPrecondition, "The item does not hold a base 10 int64 value.")
+	case types.ErrOverflow:
+		return status.Errorf(codes.OutOfRange, "The increment would overflow int64.")
+	case types.ErrInvalidBounds:
+		return status.Errorf(codes.InvalidArgument, "The min bound exceeds the max bound.")
+	case types.ErrBatchTooLarge:
+		return status.Errorf(codes.InvalidArgument, "Batch exceeds the max length of %d items.", types.BATCH_LENGTH_MAX)
+	}
+
+	return status.Error(codes.Internal, msg)
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+)
+
+// Number of keys tracked per Store when looking for hot keys, and the number of the hottest
+// ones reported in snapshots.
+const HOT_KEYS_TRACKED = 64
+const HOT_KEYS_REPORTED = 10
+
+// hotKeys approximates the most frequently accessed keys within bounded memory, using the
+// Space-Saving algorithm - once full, an untracked key replaces the least accessed one and
+// inherits its count. Counts may thus be overestimated, but never underestimated, and keys
+// accessed more often than 1/HOT_KEYS_TRACKED of the time are guaranteed to be tracked.
+// Counts get halved every minute, so that the ranking reflects recent access patterns.
+type hotKeys struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+type KeyHits struct {
+	Key  string `json:"key"`
+	Hits uint64 `json:"hits"`
+}
+
+func newHotKeys() *hotKeys {
+	return &hotKeys{
+		counts: make(map[string]uint64, HOT_KEYS_TRACKED),
+	}
+}
+
+func (h *hotKeys) touch(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if n, ok := h.counts[key]; ok || len(h.counts) < HOT_KEYS_TRACKED {
+		h.counts[key] = n + 1
+		return
+	}
+
+	var (
+		minKey string
+		min    uint64
+		first  = true
+	)
+
+	for k, n := range h.counts {
+		if first || n < min {
+			minKey, min, first = k, n, false
+		}
+	}
+
+	delete(h.counts, minKey)
+	h.counts[key] = min + 1
+}
+
+func (h *hotKeys) decay() {
+	h.mu.Lock()
+	for k, n := range h.counts {
+		if n /= 2; n == 0 {
+			delete(h.counts, k)
+		} else {
+			h.counts[k] = n
+		}
+	}
+	h.mu.Unlock()
+}
+
+// top returns up to n of the hottest keys, hottest first.
+func (h *hotKeys) top(n int) []KeyHits {
+	h.mu.Lock()
+	hits := make([]KeyHits, 0, len(h.counts))
+	for k, count := range h.counts {
+		hits = append(hits, KeyHits{k, count})
+	}
+	h.mu.Unlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Hits != hits[j].Hits {
+			return hits[i].Hits > hits[j].Hits
+		}
+		return hits[i].Key < hits[j].Key
+	})
+
+	if len(hits) > n {
+		hits = hits[:n]
+	}
+
+	return hits
+}
@@ -3,6 +3,8 @@ package metrics
 import (
 	"sync/atomic"
 	"time"
+
+	globalMetrics "github.com/js13kgames/glitchd/server/metrics"
 )
 
 type StoreAggregator struct {
@@ -12,20 +14,45 @@ type StoreAggregator struct {
 	writesSec *uint32
 	writesMin *uint32
 	writesHr  *uint32
-	length    *uint64
-	size      *uint64
+	throtSec  *uint32
+	throtMin  *uint32
+	throtHr   *uint32
+	// Monotonic totals, updated on each tick.
+	readsTotal  *uint64
+	writesTotal *uint64
+	throtTotal  *uint64
+	length      *uint64
+	size        *uint64
+	lastWrite   *int64 // Unix time in nanoseconds, 0 if there were no writes since startup.
+	hot         *hotKeys
+	history     *globalMetrics.Series
 }
 
 func NewStoreAggregator(length, size uint64) *StoreAggregator {
 	return &StoreAggregator{
-		readsSec:  new(uint32),
-		readsMin:  new(uint32),
-		readsHr:   new(uint32),
-		writesSec: new(uint32),
-		writesMin: new(uint32),
-		writesHr:  new(uint32),
-		length:    &length,
-		size:      &size,
+		readsSec:    new(uint32),
+		readsMin:    new(uint32),
+		readsHr:     new(uint32),
+		writesSec:   new(uint32),
+		writesMin:   new(uint32),
+		writesHr:    new(uint32),
+		throtSec:    new(uint32),
+		throtMin:    new(uint32),
+		throtHr:     new(uint32),
+		readsTotal:  new(uint64),
+		writesTotal: new(uint64),
+		throtTotal:  new(uint64),
+		length:      &length,
+		size:        &size,
+		lastWrite:   new(int64),
+		hot:         newHotKeys(),
+		history: globalMetrics.NewSeries(
+			globalMetrics.SeriesField{Name: "reads", Kind: globalMetrics.FieldCounter},
+			globalMetrics.SeriesField{Name: "writes", Kind: globalMetrics.FieldCounter},
+			globalMetrics.SeriesField{Name: "throttled", Kind: globalMetrics.FieldCounter},
+			globalMetrics.SeriesField{Name: "length", Kind: globalMetrics.FieldGauge},
+			globalMetrics.SeriesField{Name: "size", Kind: globalMetrics.FieldGauge},
+		),
 	}
 }
 
@@ -33,8 +60,24 @@ func (a *StoreAggregator) IncReads() {
 	atomic.AddUint32(a.readsSec, 1)
 }
 
-func (a *StoreAggregator) IncWrites(lengthDelta, sizeDelta uint64) {
-	atomic.AddUint32(a.writesSec, 1)
+func (a *StoreAggregator) AddReads(reads uint32) {
+	atomic.AddUint32(a.readsSec, reads)
+}
+
+func (a *StoreAggregator) IncThrottled() {
+	atomic.AddUint32(a.throtSec, 1)
+}
+
+// TrackKey counts an access to the given key towards the hot keys of the Store.
+func (a *StoreAggregator) TrackKey(key string) {
+	a.hot.touch(key)
+}
+
+func (a *StoreAggregator) AddWrites(writes uint32, lengthDelta, sizeDelta uint64) {
+	if writes != 0 {
+		atomic.AddUint32(a.writesSec, writes)
+		atomic.StoreInt64(a.lastWrite, time.Now().UnixNano())
+	}
 
 	if lengthDelta != 0 {
 		atomic.AddUint64(a.length, lengthDelta)
@@ -45,29 +88,64 @@ func (a *StoreAggregator) IncWrites(lengthDelta, sizeDelta uint64) {
 	}
 }
 
+func (a *StoreAggregator) Length() uint64 {
+	return atomic.LoadUint64(a.length)
+}
+
+func (a *StoreAggregator) Size() uint64 {
+	return atomic.LoadUint64(a.size)
+}
+
 func (a *StoreAggregator) OnTickSecond(tick time.Time) {
-	if r := atomic.LoadUint32(a.readsSec); r != 0 {
+	r := atomic.SwapUint32(a.readsSec, 0)
+	if r != 0 {
 		atomic.AddUint32(a.readsMin, r)
-		atomic.StoreUint32(a.readsSec, 0)
+		atomic.AddUint64(a.readsTotal, uint64(r))
 	}
 
-	if w := atomic.LoadUint32(a.writesSec); w != 0 {
+	w := atomic.SwapUint32(a.writesSec, 0)
+	if w != 0 {
 		atomic.AddUint32(a.writesMin, w)
-		atomic.StoreUint32(a.writesSec, 0)
+		atomic.AddUint64(a.writesTotal, uint64(w))
+	}
+
+	t := atomic.SwapUint32(a.throtSec, 0)
+	if t != 0 {
+		atomic.AddUint32(a.throtMin, t)
+		atomic.AddUint64(a.throtTotal, uint64(t))
 	}
+
+	a.history.Record(tick.Add(-time.Second),
+		float64(r),
+		float64(w),
+		float64(t),
+		float64(atomic.LoadUint64(a.length)),
+		float64(atomic.LoadUint64(a.size)),
+	)
 }
 
 func (a *StoreAggregator) OnTickMinute(tick time.Time) {
+	a.hot.decay()
+
 	atomic.AddUint32(a.readsHr, atomic.LoadUint32(a.readsMin))
 	atomic.StoreUint32(a.readsMin, 0)
 
 	atomic.AddUint32(a.writesHr, atomic.LoadUint32(a.writesMin))
 	atomic.StoreUint32(a.writesMin, 0)
+
+	atomic.AddUint32(a.throtHr, atomic.LoadUint32(a.throtMin))
+	atomic.StoreUint32(a.throtMin, 0)
 }
 
 func (a *StoreAggregator) OnTickHour(tick time.Time) {
 	atomic.StoreUint32(a.readsHr, 0)
 	atomic.StoreUint32(a.writesHr, 0)
+	atomic.StoreUint32(a.throtHr, 0)
+}
+
+// History returns the per second or per minute history of the Store within the given time range.
+func (a *StoreAggregator) History(from, to time.Time, resolution globalMetrics.Resolution) *globalMetrics.SeriesSnapshot {
+	return a.history.Query(from, to, resolution)
 }
 
 type StoreSnapshot struct {
@@ -78,19 +156,42 @@ type StoreSnapshot struct {
 	WritesSec uint32 `json:"writesSec"`
 	WritesMin uint32 `json:"writesMin"`
 	WritesHr  uint32 `json:"writesHr"`
-	Length    uint64 `json:"length"`
-	Size      uint64 `json:"size"`
+	ThrotSec  uint32 `json:"throttledSec"`
+	ThrotMin  uint32 `json:"throttledMin"`
+	ThrotHr   uint32 `json:"throttledHr"`
+	// Totals since startup, up to the last second.
+	ReadsTotal  uint64     `json:"readsTotal"`
+	WritesTotal uint64     `json:"writesTotal"`
+	ThrotTotal  uint64     `json:"throttledTotal"`
+	Length      uint64     `json:"length"`
+	Size        uint64     `json:"size"`
+	LastWrite   *time.Time `json:"lastWrite"`
+	HotKeys     []KeyHits  `json:"hotKeys"`
 }
 
 func (a *StoreAggregator) Collect() *StoreSnapshot {
-	return &StoreSnapshot{
-		ReadsSec:  atomic.LoadUint32(a.readsSec),
-		ReadsMin:  atomic.LoadUint32(a.readsMin),
-		ReadsHr:   atomic.LoadUint32(a.readsHr),
-		WritesSec: atomic.LoadUint32(a.writesSec),
-		WritesMin: atomic.LoadUint32(a.writesMin),
-		WritesHr:  atomic.LoadUint32(a.writesHr),
-		Length:    atomic.LoadUint64(a.length),
-		Size:      atomic.LoadUint64(a.size),
+	snapshot := &StoreSnapshot{
+		ReadsSec:    atomic.LoadUint32(a.readsSec),
+		ReadsMin:    atomic.LoadUint32(a.readsMin),
+		ReadsHr:     atomic.LoadUint32(a.readsHr),
+		WritesSec:   atomic.LoadUint32(a.writesSec),
+		WritesMin:   atomic.LoadUint32(a.writesMin),
+		WritesHr:    atomic.LoadUint32(a.writesHr),
+		ThrotSec:    atomic.LoadUint32(a.throtSec),
+		ThrotMin:    atomic.LoadUint32(a.throtMin),
+		ThrotHr:     atomic.LoadUint32(a.throtHr),
+		ReadsTotal:  atomic.LoadUint64(a.readsTotal),
+		WritesTotal: atomic.LoadUint64(a.writesTotal),
+		ThrotTotal:  atomic.LoadUint64(a.throtTotal),
+		Length:      atomic.LoadUint64(a.length),
+		Size:        atomic.LoadUint64(a.size),
+		HotKeys:     a.hot.top(HOT_KEYS_REPORTED),
 	}
+
+	if nanos := atomic.LoadInt64(a.lastWrite); nanos != 0 {
+		lastWrite := time.Unix(0, nanos)
+		snapshot.LastWrite = &lastWrite
+	}
+
+	return snapshot
 }
@@ -1,9 +1,14 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/js13kgames/glitchd/server/metrics"
+	"github.com/js13kgames/glitchd/server/services/items/storage"
 	"github.com/js13kgames/glitchd/server/services/items/types"
 )
 
@@ -29,6 +34,11 @@ func storesInsertHandler(stores *types.StoreRepository) gin.HandlerFunc {
 		}
 
 		store, err := stores.Create(store.Id, store.Token)
+		if err == types.ErrStoreExists {
+			ctx.Writer.WriteHeader(http.StatusConflict)
+			return
+		}
+
 		if err != nil {
 			ctx.Writer.WriteHeader(http.StatusInternalServerError)
 			return
@@ -53,23 +63,51 @@ func storesStorePatchHandler(stores *types.StoreRepository) gin.HandlerFunc {
 			return
 		}
 
-		if target.OwnerId != 0 {
-			source.OwnerId = target.OwnerId
-		}
-
-		if target.SubmissionId != 0 {
-			source.SubmissionId = target.SubmissionId
-		}
-
 		// A bit of special treatment for manual Token changes (even though we don't expect those to happen,
 		// the ability will be left in, in case a (temporary) lockout without purging the whole Store
-		// is necessary.
-		if target.Token != "" && target.Token != source.Token {
-			delete(stores.Items, source.Token)
-			source.Token = target.Token
-		}
+		// is necessary. Unmapping the previous Token is taken care of by the repository.
+		err := stores.Update(source, func(settings *types.StoreSettings) {
+			if target.Token != "" {
+				settings.Token = target.Token
+			}
+
+			if target.OwnerId != 0 {
+				settings.OwnerId = target.OwnerId
+			}
+
+			if target.SubmissionId != 0 {
+				settings.SubmissionId = target.SubmissionId
+			}
 
-		stores.Save(source)
+			if target.MaxItems != 0 {
+				settings.MaxItems = target.MaxItems
+			}
+
+			if target.MaxSize != 0 {
+				settings.MaxSize = target.MaxSize
+			}
+
+			if target.RateLimit != 0 {
+				settings.RateLimit = target.RateLimit
+			}
+
+			if target.RateBurst != 0 {
+				settings.RateBurst = target.RateBurst
+			}
+		})
+
+		switch err {
+		case nil:
+		case types.ErrStoreExists:
+			ctx.Writer.WriteHeader(http.StatusConflict)
+			return
+		case types.ErrStoreDeleted:
+			ctx.Writer.WriteHeader(http.StatusNotFound)
+			return
+		default:
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
 
 		ctx.Writer.WriteHeader(http.StatusNoContent)
 	}
@@ -91,28 +129,141 @@ func storesStoreDeleteHandler(stores *types.StoreRepository) gin.HandlerFunc {
 func storesStoreTokenRotateHandler(stores *types.StoreRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		resource := ctx.Keys["store"].(*types.Store)
-		stores.RotateToken(resource)
-		ctx.JSON(http.StatusOK, resource.Token)
+		token, err := stores.RotateToken(resource)
+		if err == types.ErrStoreDeleted {
+			ctx.Writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, token)
 	}
 }
 
-//
-//
-//
+// storesStoreExportHandler streams all items of a Store as a newline delimited JSON archive.
+func storesStoreExportHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		resource := ctx.Keys["store"].(*types.Store)
+
+		header := ctx.Writer.Header()
+		header.Set("Content-Type", "application/x-ndjson")
+		header.Set("Content-Disposition", `attachment; filename="store-`+strconv.FormatUint(uint64(resource.Id), 10)+`.ndjson"`)
+
+		// Headers only go out along with the first record, so failures before that can still
+		// change the status.
+		if _, err := resource.Export(ctx.Writer); err != nil {
+			if ctx.Writer.Written() {
+				ctx.Error(err)
+				return
+			}
+
+			header.Del("Content-Disposition")
+
+			if err == types.ErrStoreDeleted {
+				ctx.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+		}
+	}
+}
+
+// storesStoreImportHandler imports a newline delimited JSON archive as exported by storesStoreExportHandler
+// into a Store. Accepts mode (merge or replace, merge by default) as query param.
+func storesStoreImportHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		resource := ctx.Keys["store"].(*types.Store)
+
+		result, err := resource.Import(ctx.Request.Body, types.ImportMode(ctx.DefaultQuery("mode", string(types.ImportMerge))))
+
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusOK, result)
+		case err == types.ErrInvalidImportMode, errors.Is(err, types.ErrInvalidArchive):
+			ctx.String(http.StatusBadRequest, err.Error())
+		case err == types.ErrArchiveTooLarge:
+			ctx.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		case err == types.ErrQuotaExceeded:
+			ctx.String(http.StatusConflict, err.Error())
+		case err == types.ErrStoreDeleted:
+			ctx.AbortWithStatus(http.StatusNotFound)
+		default:
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+		}
+	}
+}
+
+// storesStoreMetricsHandler returns the metrics and stats of a Store. Read-only.
 func storesStoreMetricsHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		resource := ctx.Keys["store"].(*types.Store)
 
-		resource.Put("foo1", []byte("bar1ą"))
-		resource.Put("foo2", []byte("bar2ą"))
-		resource.Put("foo3", []byte("bar3ą"))
-		resource.Delete("foo3")
+		stats, err := resource.Stats()
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		if stats != nil {
+			ctx.JSON(http.StatusOK, stats)
+			return
+		}
+
+		ctx.Writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// storesStoreMetricsHistoryHandler returns the history of the metrics of a Store. Accepts from and to
+// (Unix time in seconds) and resolution (second or minute) as query params.
+func storesStoreMetricsHistoryHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		resource := ctx.Keys["store"].(*types.Store)
+
+		from, to, resolution, err := metrics.ParseHistoryQuery(ctx.Query("from"), ctx.Query("to"), ctx.Query("resolution"), time.Now())
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
 
-		if metrics := resource.Metrics(); metrics != nil {
-			ctx.JSON(http.StatusOK, metrics)
+		if history := resource.MetricsHistory(from, to, resolution); history != nil {
+			ctx.JSON(http.StatusOK, history)
 			return
 		}
 
 		ctx.Writer.WriteHeader(http.StatusNotFound)
 	}
 }
+
+// adminBackupHandler streams a consistent snapshot of the database, without blocking writes while
+// doing so. Failures mid-stream can't change the status anymore - clients detect them by the body
+// falling short of the Content-Length.
+func adminBackupHandler(db storage.Engine) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		snapshot, err := db.Snapshot()
+		if err == storage.ErrSnapshotsNotSupported {
+			ctx.Writer.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		defer snapshot.Close()
+
+		header := ctx.Writer.Header()
+		header.Set("Content-Type", "application/octet-stream")
+		header.Set("Content-Length", strconv.FormatInt(snapshot.Size(), 10))
+		header.Set("Content-Disposition", `attachment; filename="`+storage.SnapshotName(time.Now())+`"`)
+		ctx.Writer.WriteHeader(http.StatusOK)
+
+		if _, err := snapshot.WriteTo(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package rest
 
 import (
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -20,7 +22,7 @@ func storeFromTokenMapper(stores *types.StoreRepository) gin.HandlerFunc {
 
 		// Note: Returning 403 instead of 404 here because a Store must always be present for a valid token.
 		// No store mapped to the given token effectively means the token is invalid.
-		store := stores.Items[token]
+		store := stores.GetByToken(token)
 		if store == nil {
 			ctx.AbortWithStatus(http.StatusForbidden)
 			return
@@ -30,6 +32,17 @@ func storeFromTokenMapper(stores *types.StoreRepository) gin.HandlerFunc {
 	}
 }
 
+// storeRateLimiter rejects requests exceeding the rate limit of the Store with a 429.
+// Note: Meant to follow storeFromTokenMapper - just as it, currently not used.
+func storeRateLimiter(ctx *gin.Context) {
+	ok, wait := ctx.Keys["store"].(*types.Store).Allow(time.Now())
+	if !ok {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		ctx.AbortWithStatus(http.StatusTooManyRequests)
+		return
+	}
+}
+
 func storeFromParamMapper(stores *types.StoreRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Note: Hardcoded assumption that the route layout does not change and the param remains
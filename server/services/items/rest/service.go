@@ -3,10 +3,11 @@ package rest
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/js13kgames/glitchd/server/interfaces/http"
+	"github.com/js13kgames/glitchd/server/services/items/storage"
 	"github.com/js13kgames/glitchd/server/services/items/types"
 )
 
-func RegisterBaseRoutes(router *gin.Engine, key string, storeRepository *types.StoreRepository) {
+func RegisterBaseRoutes(router *gin.Engine, key *http.PrivilegedKey, storeRepository *types.StoreRepository) {
 	stores := router.Group("/stores", http.BearerTokenInterceptor, http.PrivilegedTokenVerifier(key))
 	stores.GET("", storesListHandler(storeRepository))
 	stores.POST("", storesInsertHandler(storeRepository))
@@ -17,14 +18,29 @@ func RegisterBaseRoutes(router *gin.Engine, key string, storeRepository *types.S
 		store.DELETE("", storesStoreDeleteHandler(storeRepository))
 
 		store.POST("/token", storesStoreTokenRotateHandler(storeRepository))
+
+		store.GET("/export", storesStoreExportHandler())
+		store.POST("/import", storesStoreImportHandler())
 	}
 }
 
-func RegisterMetricsRoutes(router *gin.Engine, key string, storeRepository *types.StoreRepository) {
+func RegisterMetricsRoutes(router *gin.Engine, key *http.PrivilegedKey, storeRepository *types.StoreRepository) {
 	router.GET("/stores/:storeId/metrics",
 		http.BearerTokenInterceptor,
 		http.PrivilegedTokenVerifier(key),
 		storeFromParamMapper(storeRepository),
 		storesStoreMetricsHandler(),
 	)
+
+	router.GET("/stores/:storeId/metrics/history",
+		http.BearerTokenInterceptor,
+		http.PrivilegedTokenVerifier(key),
+		storeFromParamMapper(storeRepository),
+		storesStoreMetricsHistoryHandler(),
+	)
+}
+
+func RegisterAdminRoutes(router *gin.Engine, key *http.PrivilegedKey, db storage.Engine) {
+	admin := router.Group("/admin", http.BearerTokenInterceptor, http.PrivilegedTokenVerifier(key))
+	admin.GET("/backup", adminBackupHandler(db))
 }
@@ -3,81 +3,208 @@ package items
 import (
 	"go.uber.org/zap"
 
+	"os"
+	"strconv"
 	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/gin-gonic/gin"
-	"github.com/js13kgames/glitchd/server"
+	"github.com/js13kgames/glitchd/server/config"
 	"github.com/js13kgames/glitchd/server/interfaces"
+	"github.com/js13kgames/glitchd/server/interfaces/http"
+	"github.com/js13kgames/glitchd/server/metrics"
 	"github.com/js13kgames/glitchd/server/services"
 	grpcService "github.com/js13kgames/glitchd/server/services/items/grpc"
+	itemsMetrics "github.com/js13kgames/glitchd/server/services/items/metrics"
 	restService "github.com/js13kgames/glitchd/server/services/items/rest"
+	"github.com/js13kgames/glitchd/server/services/items/storage"
 	"github.com/js13kgames/glitchd/server/services/items/types"
 	metricsService "github.com/js13kgames/glitchd/server/services/metrics"
 )
 
+// MaxRecvMsgSize is the size of the largest message the items service expects to receive - a full
+// batch of items with max sized keys and values, plus some headroom for their encoding. Limits on
+// individual items are enforced by the Store itself.
+const MaxRecvMsgSize = types.BATCH_LENGTH_MAX * (types.KEY_SIZE_MAX + types.ITEM_SIZE_MAX + 16)
+
 type ItemsService struct {
 	logger  *zap.Logger
-	restKey string
-	stores  *types.StoreRepository
+	restKey *http.PrivilegedKey
+	db      storage.Engine
+	// Nil unless group commit is enabled.
+	groupCommit *storage.GroupCommit
+	snapshots   config.SnapshotsConfig
+	stores      *types.StoreRepository
+	// Names of the interfaces to serve the gRPC and REST APIs on. Empty serves them on all interfaces.
+	ifaces []string
 }
 
-func NewItemsService(db *bolt.DB, bucketKey []byte, key string, logger *zap.Logger) *ItemsService {
-	// @todo Validate the params - once we have a proper config pipeline in place.
+// NewItemsService opens the storage engine selected by the config and loads the stores persisted
+// in the given bucket. The engine stays open until Close gets called.
+func NewItemsService(dbConfig config.DbConfig, bucketKey []byte, key *http.PrivilegedKey, ifaces []string, logger *zap.Logger) (*ItemsService, error) {
+	if dbConfig.Snapshots.Dir != "" {
+		if err := os.MkdirAll(dbConfig.Snapshots.Dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := storage.Open(dbConfig.Engine, dbConfig.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupCommit *storage.GroupCommit
+	if dbConfig.GroupCommit.Enabled {
+		groupCommit = storage.NewGroupCommit(db, dbConfig.GroupCommit.MaxDelay, dbConfig.GroupCommit.MaxSize)
+		db = groupCommit
+	}
+
 	stores, err := types.LoadStoreRepository(db, bucketKey)
 	if err != nil {
-		logger.Fatal(err.Error())
+		db.Close()
+		return nil, err
 	}
 
 	return &ItemsService{
-		logger:  logger,
-		restKey: key,
-		stores:  stores,
-	}
+		logger:      logger,
+		restKey:     key,
+		db:          db,
+		groupCommit: groupCommit,
+		snapshots:   dbConfig.Snapshots,
+		stores:      stores,
+		ifaces:      ifaces,
+	}, nil
 }
 
 func (service *ItemsService) GetName() string {
 	return "items"
 }
 
-func (service *ItemsService) Bootstrap(manager *services.Manager, ifaces []server.Interface, srvcs []services.Service) {
+func (service *ItemsService) GetDependencies() []services.Dependency {
+	return append(services.RequiresInterfaces(service.ifaces), services.WantsService("metrics"))
+}
 
+func (service *ItemsService) Bootstrap(manager *services.Manager) error {
 	var httpHandlers []*gin.Engine
 
-	for _, iface := range ifaces {
+	for _, iface := range manager.SelectInterfaces(service.ifaces) {
 		switch v := iface.(type) {
-
-		// @todo While we currently retain full control and knowledge of what's being
-		// initialized, once proper configs are in place and/or services become pluggable,
-		// this will also need a means of filtering through interfaces on other criteria
-		// than just their type.
 		case *interfaces.GrpcServerInterface:
 			grpcService.RegisterStoreServer(v.GetServer(), &grpcService.Service{})
 			v.PushUnaryInterceptor(grpcService.UnaryStoreExtractor(service.stores))
+			v.PushUnaryInterceptor(grpcService.UnaryRateLimiter())
+			v.PushStreamInterceptor(grpcService.StreamStoreExtractor(service.stores))
+			v.PushStreamInterceptor(grpcService.StreamRateLimiter())
 
 		case *interfaces.HttpServerInterface:
 			httpHandlers = append(httpHandlers, v.GetHandler())
 			restService.RegisterBaseRoutes(v.GetHandler(), service.restKey, service.stores)
+			restService.RegisterAdminRoutes(v.GetHandler(), service.restKey, service.db)
 		}
 	}
 
-	for _, srvc := range srvcs {
-		if _, ok := srvc.(*metricsService.MetricsService); ok {
-			for _, handler := range httpHandlers {
-				restService.RegisterMetricsRoutes(handler, service.restKey, service.stores)
-				service.stores.RegisterMetricsTicks(manager)
-			}
-			// Can't imagine a reason for there being more than one Metrics service registered
-			// at runtime.
-			break
+	manager.OnTickMinute(service.sweepExpired)
+
+	if service.snapshots.Dir != "" {
+		manager.OnTickHour(service.writeSnapshot)
+	}
+
+	if v, ok := manager.GetService("metrics").(*metricsService.MetricsService); ok {
+		for _, handler := range httpHandlers {
+			restService.RegisterMetricsRoutes(handler, service.restKey, service.stores)
 		}
+		service.stores.RegisterMetricsTicks(manager)
+		v.RegisterCollector(service.collectMetrics)
 	}
+
+	return nil
 }
 
-func (service *ItemsService) Start() {
+// sweepExpired is a TickHandler which removes expired items from all stores.
+func (service *ItemsService) sweepExpired(tick time.Time) {
+	if err := service.stores.SweepExpired(tick); err != nil {
+		service.logger.Error("Failed to sweep expired items", zap.Error(err))
+	}
+}
+
+// writeSnapshot is a TickHandler which writes a snapshot of the database and prunes the ones
+// exceeding the retention count.
+func (service *ItemsService) writeSnapshot(tick time.Time) {
+	path, err := storage.WriteSnapshotFile(service.db, service.snapshots.Dir, tick)
+	if err != nil {
+		service.logger.Error("Failed to write a snapshot", zap.Error(err), zap.String("dir", service.snapshots.Dir))
+		return
+	}
+
+	service.logger.Info("Snapshot written", zap.String("path", path))
+
+	removed, err := storage.PruneSnapshots(service.snapshots.Dir, service.snapshots.Retain)
+	if err != nil {
+		service.logger.Error("Failed to prune snapshots", zap.Error(err), zap.String("dir", service.snapshots.Dir))
+	}
+
+	for _, path := range removed {
+		service.logger.Debug("Snapshot pruned", zap.String("path", path))
+	}
+}
+
+// collectMetrics is a PrometheusCollector writing the metrics of all stores, labeled by store ID.
+func (service *ItemsService) collectMetrics(w *metrics.PrometheusWriter) {
+	var (
+		ids       []string
+		snapshots []*itemsMetrics.StoreSnapshot
+	)
+
+	for _, store := range service.stores.All() {
+		if snapshot := store.Metrics(); snapshot != nil {
+			ids = append(ids, strconv.FormatUint(uint64(store.Id), 10))
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	families := []struct {
+		name  string
+		kind  string
+		help  string
+		value func(s *itemsMetrics.StoreSnapshot) uint64
+	}{
+		{"glitchd_store_reads_total", "counter", "Number of item reads per store.", func(s *itemsMetrics.StoreSnapshot) uint64 { return s.ReadsTotal }},
+		{"glitchd_store_writes_total", "counter", "Number of item writes per store.", func(s *itemsMetrics.StoreSnapshot) uint64 { return s.WritesTotal }},
+		{"glitchd_store_throttled_total", "counter", "Number of requests rejected by the rate limit per store.", func(s *itemsMetrics.StoreSnapshot) uint64 { return s.ThrotTotal }},
+		{"glitchd_store_items", "gauge", "Number of items per store.", func(s *itemsMetrics.StoreSnapshot) uint64 { return s.Length }},
+		{"glitchd_store_size_bytes", "gauge", "Total size of the item values per store, in bytes.", func(s *itemsMetrics.StoreSnapshot) uint64 { return s.Size }},
+	}
+
+	for _, family := range families {
+		w.Family(family.name, family.kind, family.help)
+		for i, snapshot := range snapshots {
+			w.Sample(family.name, float64(family.value(snapshot)), "store_id", ids[i])
+		}
+	}
+	if service.groupCommit != nil {
+		stats := service.groupCommit.Stats()
+
+		w.Family("glitchd_storage_batches_total", "counter", "Number of group commits.")
+		w.Sample("glitchd_storage_batches_total", float64(stats.Batches))
+		w.Family("glitchd_storage_batched_writes_total", "counter", "Number of writes committed in groups.")
+		w.Sample("glitchd_storage_batched_writes_total", float64(stats.Calls))
+		w.Family("glitchd_storage_batch_retries_total", "counter", "Number of writes which failed within a group and got re-run on their own.")
+		w.Sample("glitchd_storage_batch_retries_total", float64(stats.Retries))
+		w.Family("glitchd_storage_batch_commit_seconds_total", "counter", "Total time spent committing groups, in seconds.")
+		w.Sample("glitchd_storage_batch_commit_seconds_total", stats.CommitSeconds)
+	}
+}
+
+func (service *ItemsService) Start() error {
 	// No-op - we only register with global interfaces.
+	return nil
 }
 
 func (service *ItemsService) Stop(deadline *time.Time) {
 	// No-op - we only register with global interfaces.
 }
+
+// Close closes the storage engine. Must only be called once the interfaces the service is
+// registered with have been stopped, as requests in flight may still hit the engine until then.
+func (service *ItemsService) Close() error {
+	return service.db.Close()
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"github.com/boltdb/bolt"
+)
+
+// Bolt is an Engine backed by a bolt database file.
+type Bolt struct {
+	db *bolt.DB
+}
+
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+func (engine *Bolt) View(fn func(tx Tx) error) error {
+	return engine.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (engine *Bolt) Update(fn func(tx Tx) error) error {
+	return engine.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (engine *Bolt) Batch(fn func(tx Tx) error) error {
+	return engine.Update(fn)
+}
+
+func (engine *Bolt) Snapshot() (Snapshot, error) {
+	tx, err := engine.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return boltSnapshot{tx}, nil
+}
+
+func (engine *Bolt) Close() error {
+	return engine.db.Close()
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (tx boltTx) Bucket(name []byte) Bucket {
+	// Avoid returning a typed nil.
+	if bucket := tx.tx.Bucket(name); bucket != nil {
+		return boltBucket{bucket}
+	}
+
+	return nil
+}
+
+func (tx boltTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	bucket, err := tx.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return boltBucket{bucket}, nil
+}
+
+func (tx boltTx) DeleteBucket(name []byte) error {
+	if err := tx.tx.DeleteBucket(name); err != bolt.ErrBucketNotFound {
+		return err
+	}
+
+	return ErrBucketNotFound
+}
+
+type boltSnapshot struct {
+	*bolt.Tx
+}
+
+func (snapshot boltSnapshot) Close() error {
+	return snapshot.Rollback()
+}
+
+// boltBucket embeds *bolt.Bucket for everything but Cursor, whose return type differs.
+type boltBucket struct {
+	*bolt.Bucket
+}
+
+func (bucket boltBucket) Cursor() Cursor {
+	return bucket.Bucket.Cursor()
+}
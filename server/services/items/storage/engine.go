@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Engines which can be opened by name.
+const (
+	ENGINE_BOLT   = "bolt"
+	ENGINE_MEMORY = "memory"
+)
+
+var (
+	ErrTxNotWritable         = errors.New("storage: transaction not writable")
+	ErrBucketNotFound        = errors.New("storage: bucket not found")
+	ErrSnapshotsNotSupported = errors.New("storage: engine does not support snapshots")
+)
+
+// Engine is an ordered key/value store with buckets and serializable transactions. The semantics
+// follow bolt's - keys within a bucket are ordered bytewise and values returned within a transaction
+// are only valid for its lifetime and must not be modified.
+type Engine interface {
+	// View runs fn within a read-only transaction.
+	View(fn func(tx Tx) error) error
+	// Update runs fn within a read-write transaction, which gets committed if fn returns nil
+	// and rolled back otherwise.
+	Update(fn func(tx Tx) error) error
+	// Batch runs fn within a read-write transaction, which engines with group commit may share with
+	// concurrent calls. fn may get called more than once and has to be idempotent. Other engines
+	// simply run it as an Update.
+	Batch(fn func(tx Tx) error) error
+	// Snapshot opens a consistent, read-only view of the whole database which can be written out
+	// as a database file of the engine, without blocking writers. Returns ErrSnapshotsNotSupported
+	// if the engine has no file format.
+	Snapshot() (Snapshot, error)
+	Close() error
+}
+
+// Snapshot of the database. Must be closed, as it holds on to a read transaction.
+type Snapshot interface {
+	// Size of the database file in bytes, as written by WriteTo.
+	Size() int64
+	WriteTo(w io.Writer) (int64, error)
+	Close() error
+}
+
+type Tx interface {
+	// Bucket returns the bucket with the given name, or nil if it does not exist.
+	Bucket(name []byte) Bucket
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	DeleteBucket(name []byte) error
+}
+
+type Bucket interface {
+	// Get returns the value of the key, or nil if it does not exist.
+	Get(key []byte) []byte
+	Put(key []byte, value []byte) error
+	Delete(key []byte) error
+	// Cursor returns a cursor over the keys of the bucket in order. The bucket must not be
+	// modified while a cursor walks it.
+	Cursor() Cursor
+	// ForEach calls fn for every key in order, stopping at the first error.
+	ForEach(fn func(k, v []byte) error) error
+	Sequence() uint64
+	NextSequence() (uint64, error)
+}
+
+// Cursor methods return a nil key once the cursor moved past the last key.
+type Cursor interface {
+	First() (key []byte, value []byte)
+	Next() (key []byte, value []byte)
+	// Seek moves the cursor to the given key, or the next one if it does not exist.
+	Seek(seek []byte) (key []byte, value []byte)
+}
+
+// Open opens the engine of the given kind. The path is ignored by the memory engine.
+func Open(engine string, path string) (Engine, error) {
+	switch engine {
+	case ENGINE_BOLT:
+		return OpenBolt(path)
+	case ENGINE_MEMORY:
+		return NewMemory(), nil
+	}
+
+	return nil, fmt.Errorf("storage: unknown engine %q", engine)
+}
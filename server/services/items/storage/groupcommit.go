@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errTrySolo signals a call that its batch failed on it and that it has to be retried on its own.
+var errTrySolo = errors.New("storage: batch call has to be retried on its own")
+
+// GroupCommit wraps an Engine, coalescing concurrent Batch calls into a single read-write transaction
+// to amortize the cost of commits (fsyncs, in case of bolt). Batches get committed once they are full
+// or the first call in them has waited for maxDelay, whichever comes first. Calls return only once
+// their batch has been committed.
+// If a call fails, its batch gets rolled back and re-run without it, and the failed call gets re-run
+// in its own transaction - so calls may run more than once and have to be idempotent. Same as bolt's
+// DB.Batch, which can't be used directly as it doesn't expose any metrics.
+type GroupCommit struct {
+	// Stats. First, for 64-bit alignment of atomic ops.
+	batches     uint64
+	calls       uint64
+	retries     uint64
+	commitNanos uint64
+
+	Engine
+	maxDelay time.Duration
+	maxSize  int
+
+	mu    sync.Mutex
+	batch *groupBatch
+}
+
+type groupBatch struct {
+	engine *GroupCommit
+	timer  *time.Timer
+	once   sync.Once
+	calls  []groupCall
+}
+
+type groupCall struct {
+	fn  func(tx Tx) error
+	err chan error
+}
+
+func NewGroupCommit(engine Engine, maxDelay time.Duration, maxSize int) *GroupCommit {
+	return &GroupCommit{
+		Engine:   engine,
+		maxDelay: maxDelay,
+		maxSize:  maxSize,
+	}
+}
+
+func (engine *GroupCommit) Batch(fn func(tx Tx) error) error {
+	call := groupCall{fn: fn, err: make(chan error, 1)}
+
+	engine.mu.Lock()
+	if engine.batch == nil {
+		batch := &groupBatch{engine: engine}
+		batch.timer = time.AfterFunc(engine.maxDelay, batch.trigger)
+		engine.batch = batch
+	}
+
+	batch := engine.batch
+	batch.calls = append(batch.calls, call)
+
+	// Detach full batches right away, so that further calls start a new one.
+	if len(batch.calls) >= engine.maxSize {
+		engine.batch = nil
+		go batch.trigger()
+	}
+	engine.mu.Unlock()
+
+	err := <-call.err
+	if err == errTrySolo {
+		atomic.AddUint64(&engine.retries, 1)
+		err = engine.Engine.Update(fn)
+	}
+
+	return err
+}
+
+// Close commits the pending batch, if any, before closing the wrapped Engine.
+func (engine *GroupCommit) Close() error {
+	engine.mu.Lock()
+	batch := engine.batch
+	engine.batch = nil
+	engine.mu.Unlock()
+
+	if batch != nil {
+		batch.trigger()
+	}
+
+	return engine.Engine.Close()
+}
+
+type GroupCommitStats struct {
+	// Number of batches committed and calls in them - their ratio is the mean batch size.
+	Batches uint64 `json:"batches"`
+	Calls   uint64 `json:"calls"`
+	// Number of calls which failed within a batch and got re-run on their own.
+	Retries uint64 `json:"retries"`
+	// Total time spent committing batches, in seconds.
+	CommitSeconds float64 `json:"commitSeconds"`
+}
+
+func (engine *GroupCommit) Stats() GroupCommitStats {
+	return GroupCommitStats{
+		Batches:       atomic.LoadUint64(&engine.batches),
+		Calls:         atomic.LoadUint64(&engine.calls),
+		Retries:       atomic.LoadUint64(&engine.retries),
+		CommitSeconds: time.Duration(atomic.LoadUint64(&engine.commitNanos)).Seconds(),
+	}
+}
+
+func (batch *groupBatch) trigger() {
+	batch.once.Do(batch.run)
+}
+
+func (batch *groupBatch) run() {
+	engine := batch.engine
+
+	engine.mu.Lock()
+	batch.timer.Stop()
+	if engine.batch == batch {
+		engine.batch = nil
+	}
+	engine.mu.Unlock()
+
+	for len(batch.calls) != 0 {
+		failed := -1
+		start := time.Now()
+
+		err := engine.Engine.Update(func(tx Tx) error {
+			for i, call := range batch.calls {
+				if err := safelyCall(call.fn, tx); err != nil {
+					failed = i
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if failed >= 0 {
+			// Drop the failed call and re-run the rest.
+			call := batch.calls[failed]
+			batch.calls = append(batch.calls[:failed], batch.calls[failed+1:]...)
+			call.err <- errTrySolo
+			continue
+		}
+
+		if err == nil {
+			atomic.AddUint64(&engine.batches, 1)
+			atomic.AddUint64(&engine.calls, uint64(len(batch.calls)))
+			atomic.AddUint64(&engine.commitNanos, uint64(time.Since(start)))
+		}
+
+		// Errors at this point are failures to commit, shared by all calls.
+		for _, call := range batch.calls {
+			call.err <- err
+		}
+
+		return
+	}
+}
+
+// safelyCall turns panics into errors, so that the call gets re-run on its own - and panics in the
+// goroutine of its caller instead of taking the batch down.
+func safelyCall(fn func(tx Tx) error, tx Tx) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("storage: panic in batch call: %v", p)
+		}
+	}()
+
+	return fn(tx)
+}
@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var ErrEngineClosed = errors.New("storage: engine closed")
+
+// Memory is an Engine keeping everything in memory - for tests and ephemeral instances. Nothing
+// survives a restart. Writers are serialized and exclude readers for the duration of their transaction.
+type Memory struct {
+	mu      sync.RWMutex
+	buckets map[string]*memoryData
+	closed  bool
+}
+
+// memoryData holds the items of a bucket, ordered by key.
+type memoryData struct {
+	entries  []memoryEntry
+	sequence uint64
+}
+
+// Entries never get modified in place - values get replaced instead, so that values handed out
+// remain valid (and unchanged) for the lifetime of the transaction.
+type memoryEntry struct {
+	key   []byte
+	value []byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]*memoryData),
+	}
+}
+
+func (engine *Memory) View(fn func(tx Tx) error) error {
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+
+	if engine.closed {
+		return ErrEngineClosed
+	}
+
+	return fn(&memoryTx{engine: engine})
+}
+
+// Update keeps an undo log of the changes made by fn, which gets replayed in reverse if fn
+// returns an error or panics.
+func (engine *Memory) Update(fn func(tx Tx) error) error {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	if engine.closed {
+		return ErrEngineClosed
+	}
+
+	var (
+		tx        = &memoryTx{engine: engine, writable: true}
+		committed bool
+	)
+
+	defer func() {
+		if !committed {
+			tx.rollback()
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	committed = true
+
+	return nil
+}
+
+func (engine *Memory) Batch(fn func(tx Tx) error) error {
+	return engine.Update(fn)
+}
+
+func (engine *Memory) Snapshot() (Snapshot, error) {
+	return nil, ErrSnapshotsNotSupported
+}
+
+func (engine *Memory) Close() error {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	engine.closed = true
+	engine.buckets = nil
+
+	return nil
+}
+
+type memoryTx struct {
+	engine   *Memory
+	writable bool
+	undo     []func()
+}
+
+func (tx *memoryTx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+}
+
+func (tx *memoryTx) Bucket(name []byte) Bucket {
+	if data := tx.engine.buckets[string(name)]; data != nil {
+		return &memoryBucket{tx: tx, data: data}
+	}
+
+	return nil
+}
+
+func (tx *memoryTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if !tx.writable {
+		return nil, ErrTxNotWritable
+	}
+
+	key := string(name)
+
+	data := tx.engine.buckets[key]
+	if data == nil {
+		data = &memoryData{}
+		tx.engine.buckets[key] = data
+		tx.undo = append(tx.undo, func() { delete(tx.engine.buckets, key) })
+	}
+
+	return &memoryBucket{tx: tx, data: data}, nil
+}
+
+func (tx *memoryTx) DeleteBucket(name []byte) error {
+	if !tx.writable {
+		return ErrTxNotWritable
+	}
+
+	key := string(name)
+
+	data := tx.engine.buckets[key]
+	if data == nil {
+		return ErrBucketNotFound
+	}
+
+	delete(tx.engine.buckets, key)
+	tx.undo = append(tx.undo, func() { tx.engine.buckets[key] = data })
+
+	return nil
+}
+
+type memoryBucket struct {
+	tx   *memoryTx
+	data *memoryData
+}
+
+func (bucket *memoryBucket) Get(key []byte) []byte {
+	if i, ok := bucket.data.search(key); ok {
+		return bucket.data.entries[i].value
+	}
+
+	return nil
+}
+
+func (bucket *memoryBucket) Put(key []byte, value []byte) error {
+	if !bucket.tx.writable {
+		return ErrTxNotWritable
+	}
+
+	// Callers may reuse their buffers once Put returns.
+	key = append([]byte(nil), key...)
+	value = append(make([]byte, 0, len(value)), value...)
+
+	data := bucket.data
+	if prev := bucket.Get(key); prev != nil {
+		bucket.tx.undo = append(bucket.tx.undo, func() { data.set(key, prev) })
+	} else {
+		bucket.tx.undo = append(bucket.tx.undo, func() { data.unset(key) })
+	}
+
+	data.set(key, value)
+
+	return nil
+}
+
+func (bucket *memoryBucket) Delete(key []byte) error {
+	if !bucket.tx.writable {
+		return ErrTxNotWritable
+	}
+
+	data := bucket.data
+	if prev := bucket.Get(key); prev != nil {
+		key = append([]byte(nil), key...)
+		bucket.tx.undo = append(bucket.tx.undo, func() { data.set(key, prev) })
+		data.unset(key)
+	}
+
+	return nil
+}
+
+func (bucket *memoryBucket) Cursor() Cursor {
+	return &memoryCursor{data: bucket.data}
+}
+
+func (bucket *memoryBucket) ForEach(fn func(k, v []byte) error) error {
+	cur := bucket.Cursor()
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bucket *memoryBucket) Sequence() uint64 {
+	return bucket.data.sequence
+}
+
+func (bucket *memoryBucket) NextSequence() (uint64, error) {
+	if !bucket.tx.writable {
+		return 0, ErrTxNotWritable
+	}
+
+	data := bucket.data
+	data.sequence++
+	bucket.tx.undo = append(bucket.tx.undo, func() { data.sequence-- })
+
+	return data.sequence, nil
+}
+
+// search returns the index of the first entry with a key >= the given key, and whether
+// the keys are equal.
+func (data *memoryData) search(key []byte) (int, bool) {
+	i := sort.Search(len(data.entries), func(i int) bool {
+		return bytes.Compare(data.entries[i].key, key) >= 0
+	})
+
+	return i, i < len(data.entries) && bytes.Equal(data.entries[i].key, key)
+}
+
+func (data *memoryData) set(key []byte, value []byte) {
+	i, ok := data.search(key)
+	if ok {
+		data.entries[i].value = value
+		return
+	}
+
+	data.entries = append(data.entries, memoryEntry{})
+	copy(data.entries[i+1:], data.entries[i:])
+	data.entries[i] = memoryEntry{key: key, value: value}
+}
+
+func (data *memoryData) unset(key []byte) {
+	if i, ok := data.search(key); ok {
+		data.entries = append(data.entries[:i], data.entries[i+1:]...)
+	}
+}
+
+type memoryCursor struct {
+	data *memoryData
+	i    int
+}
+
+func (cur *memoryCursor) First() ([]byte, []byte) {
+	cur.i = 0
+	return cur.current()
+}
+
+func (cur *memoryCursor) Next() ([]byte, []byte) {
+	cur.i++
+	return cur.current()
+}
+
+func (cur *memoryCursor) Seek(seek []byte) ([]byte, []byte) {
+	cur.i, _ = cur.data.search(seek)
+	return cur.current()
+}
+
+func (cur *memoryCursor) current() ([]byte, []byte) {
+	if cur.i >= len(cur.data.entries) {
+		return nil, nil
+	}
+
+	entry := cur.data.entries[cur.i]
+	return entry.key, entry.value
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot files are named after the (UTC) time they were taken at, so that they sort chronologically.
+const (
+	SNAPSHOT_PREFIX      = "glitchd-"
+	SNAPSHOT_EXT         = ".db"
+	SNAPSHOT_TIME_FORMAT = "20060102T150405Z"
+)
+
+// SnapshotName returns the file name of a snapshot taken at the given time.
+func SnapshotName(t time.Time) string {
+	return SNAPSHOT_PREFIX + t.UTC().Format(SNAPSHOT_TIME_FORMAT) + SNAPSHOT_EXT
+}
+
+// WriteSnapshotFile writes a snapshot of the engine into dir, named after the given time, and
+// returns its path. The file only appears under its name once it has been completely written.
+func WriteSnapshotFile(engine Engine, dir string, t time.Time) (string, error) {
+	snapshot, err := engine.Snapshot()
+	if err != nil {
+		return "", err
+	}
+	defer snapshot.Close()
+
+	tmp, err := ioutil.TempFile(dir, SNAPSHOT_PREFIX+"*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = snapshot.WriteTo(tmp); err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	path := filepath.Join(dir, SnapshotName(t))
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return path, nil
+}
+
+// PruneSnapshots removes all but the latest retain snapshots in dir and returns the paths of
+// the removed ones. Other files in dir are left alone.
+func PruneSnapshots(dir string, retain int) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		name := file.Name()
+		if !file.Mode().IsRegular() || !strings.HasPrefix(name, SNAPSHOT_PREFIX) || !strings.HasSuffix(name, SNAPSHOT_EXT) {
+			continue
+		}
+
+		if _, err := time.Parse(SNAPSHOT_TIME_FORMAT, strings.TrimSuffix(strings.TrimPrefix(name, SNAPSHOT_PREFIX), SNAPSHOT_EXT)); err == nil {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) <= retain {
+		return nil, nil
+	}
+
+	sort.Strings(names)
+
+	var removed []string
+	for _, name := range names[:len(names)-retain] {
+		path := filepath.Join(dir, name)
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+
+	return removed, nil
+}
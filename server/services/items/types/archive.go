@@ -0,0 +1,221 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/js13kgames/glitchd/server/services/items/storage"
+)
+
+// Max size of an archive accepted by Store.Import. Archives get decoded in full before being
+// imported in a single transaction, so they have to fit in memory.
+const IMPORT_SIZE_MAX = 64 * 1024 * 1024
+
+var (
+	ErrInvalidArchive    = errors.New("invalid archive")
+	ErrArchiveTooLarge   = errors.New("archive exceeds the max import size")
+	ErrInvalidImportMode = errors.New("invalid import mode")
+)
+
+// ArchiveRecord is a single item in an archive of a Store. Archives are newline delimited JSON,
+// one record per line, ordered by key. Values are base64 encoded.
+type ArchiveRecord struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+	// Version of the item in the exported Store. Informational only - imported items get
+	// new versions in the importing Store.
+	Version   uint64     `json:"version,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type ImportMode string
+
+const (
+	// Merge imports the archived items on top of the existing ones, overwriting those with the same keys.
+	ImportMerge ImportMode = "merge"
+	// Replace additionally deletes all existing items which are not in the archive.
+	ImportReplace ImportMode = "replace"
+)
+
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Deleted  int `json:"deleted"`
+	// Records which had expired already by the time of the import.
+	Skipped int `json:"skipped"`
+}
+
+// Export writes all items of the Store as an archive, from a consistent snapshot. Expired items
+// which have not been swept yet are left out. Returns the number of items written.
+// Note: The snapshot gets held on to until the archive has been fully written, so slow writers
+// keep a read transaction open for as long.
+func (store *Store) Export(w io.Writer) (int, error) {
+	var n int
+
+	err := store.db.View(func(tx storage.Tx) error {
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		var (
+			encoder = json.NewEncoder(w)
+			now     = time.Now().UnixNano()
+			cur     = b.items.Cursor()
+		)
+
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			meta := decodeMeta(b.meta.Get(k))
+			if meta.expired(now) {
+				continue
+			}
+
+			record := ArchiveRecord{
+				Key:     string(k),
+				Value:   v,
+				Version: meta.version,
+			}
+
+			if meta.expiresAt != 0 {
+				expiresAt := time.Unix(0, meta.expiresAt).UTC()
+				record.ExpiresAt = &expiresAt
+			}
+
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+
+			n++
+		}
+
+		return nil
+	})
+
+	return n, err
+}
+
+// Import reads an archive as written by Export and imports its items in a single transaction -
+// either all of them get imported, or none do. Records with the same key overwrite each other,
+// in order. Imported items are subject to the quotas of the Store, reach watchers like any other
+// write and get accounted for in the length and size metrics, but do not count as writes.
+func (store *Store) Import(r io.Reader, mode ImportMode) (*ImportResult, error) {
+	if mode != ImportMerge && mode != ImportReplace {
+		return nil, ErrInvalidImportMode
+	}
+
+	records, err := readArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		delta  writeDelta
+		result ImportResult
+	)
+
+	if err := store.db.Update(func(tx storage.Tx) error {
+		delta = writeDelta{}
+		result = ImportResult{}
+
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		// Delete first, so that the quotas account for the space freed up.
+		if mode == ImportReplace {
+			var stale [][]byte
+
+			cur := b.items.Cursor()
+			for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+				if _, ok := records[string(k)]; !ok {
+					stale = append(stale, append([]byte(nil), k...))
+				}
+			}
+
+			for _, key := range stale {
+				if err := store.remove(b, key, b.items.Get(key), decodeMeta(b.meta.Get(key)), &delta); err != nil {
+					return err
+				}
+				result.Deleted++
+			}
+		}
+
+		now := time.Now()
+
+		for _, key := range keys {
+			record := records[key]
+
+			var ttl time.Duration
+			if record.ExpiresAt != nil {
+				if ttl = record.ExpiresAt.Sub(now); ttl <= 0 {
+					result.Skipped++
+					continue
+				}
+			}
+
+			if _, err := store.put(b, []byte(key), record.Value, ttl, Precondition{}, &delta); err != nil {
+				return err
+			}
+			result.Imported++
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	delta.writes = 0
+	store.applyDelta(&delta)
+
+	return &result, nil
+}
+
+// readArchive decodes and validates all records of an archive, keyed by their keys.
+func readArchive(r io.Reader) (map[string]*ArchiveRecord, error) {
+	var (
+		limited = &io.LimitedReader{R: r, N: IMPORT_SIZE_MAX + 1}
+		decoder = json.NewDecoder(limited)
+		records = make(map[string]*ArchiveRecord)
+	)
+
+	for line := 1; ; line++ {
+		var record ArchiveRecord
+
+		err := decoder.Decode(&record)
+		if limited.N == 0 {
+			return nil, ErrArchiveTooLarge
+		}
+
+		if err == io.EOF {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidArchive, line, err)
+		}
+
+		if err := checkKey([]byte(record.Key)); err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidArchive, line, err)
+		}
+
+		if len(record.Value) > ITEM_SIZE_MAX {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidArchive, line, ErrItemTooLarge)
+		}
+
+		if record.Value == nil {
+			record.Value = []byte{}
+		}
+
+		records[record.Key] = &record
+	}
+}
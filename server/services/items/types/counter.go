@@ -0,0 +1,103 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/js13kgames/glitchd/server/services/items/storage"
+)
+
+var (
+	ErrNotAnInteger  = errors.New("item value is not a base 10 int64")
+	ErrOverflow      = errors.New("increment overflows int64")
+	ErrInvalidBounds = errors.New("min bound exceeds max bound")
+)
+
+// Bounds the result of an Increment gets clamped to. Nil bounds are not applied.
+type Bounds struct {
+	Min *int64
+	Max *int64
+}
+
+// Increment atomically adds delta to the int64 stored under the given key and returns the
+// resulting value along with the item's new version. Absent (or expired) items are treated
+// as 0. Counters are stored as base 10 strings, so they can be read (and set) just as any
+// other item. Just like Put without a ttl, incrementing makes the item persistent.
+func (store *Store) Increment(key string, delta int64, bounds Bounds) (int64, uint64, error) {
+	if bounds.Min != nil && bounds.Max != nil && *bounds.Min > *bounds.Max {
+		return 0, 0, ErrInvalidBounds
+	}
+
+	if err := checkKey([]byte(key)); err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		wd      writeDelta
+		value   int64
+		version uint64
+	)
+
+	if err := store.db.Batch(func(tx storage.Tx) (err error) {
+		wd = writeDelta{}
+		value = 0
+
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+		keyBytes := []byte(key)
+
+		if v := b.items.Get(keyBytes); v != nil && !decodeMeta(b.meta.Get(keyBytes)).expired(time.Now().UnixNano()) {
+			if value, err = strconv.ParseInt(string(v), 10, 64); err != nil {
+				return ErrNotAnInteger
+			}
+		}
+
+		if value, err = bounds.add(value, delta); err != nil {
+			return err
+		}
+
+		version, err = store.put(b, keyBytes, []byte(strconv.FormatInt(value, 10)), 0, Precondition{}, &wd)
+		return err
+	}); err != nil {
+		return 0, 0, err
+	}
+
+	store.applyDelta(&wd)
+
+	return value, version, nil
+}
+
+// add returns value + delta clamped to the bounds. Overflowing int64 is an error, unless
+// there is a bound in the direction of the overflow to clamp to.
+func (bounds Bounds) add(value, delta int64) (int64, error) {
+	switch {
+	case delta > 0 && value > math.MaxInt64-delta:
+		if bounds.Max == nil {
+			return 0, ErrOverflow
+		}
+		value = math.MaxInt64
+
+	case delta < 0 && value < math.MinInt64-delta:
+		if bounds.Min == nil {
+			return 0, ErrOverflow
+		}
+		value = math.MinInt64
+
+	default:
+		value += delta
+	}
+
+	if bounds.Max != nil && value > *bounds.Max {
+		value = *bounds.Max
+	}
+
+	if bounds.Min != nil && value < *bounds.Min {
+		value = *bounds.Min
+	}
+
+	return value, nil
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrPreconditionFailed = errors.New("item does not satisfy the write precondition")
+	ErrInvalidKey         = errors.New("key is empty or exceeds the max key size")
+)
+
+type Item struct {
+	Key     string
+	Value   []byte
+	Version uint64
+}
+
+// checkKey verifies that the key is non-empty and within KEY_SIZE_MAX.
+func checkKey(key []byte) error {
+	if len(key) == 0 || len(key) > KEY_SIZE_MAX {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+// Precondition guards conditional writes. The zero value imposes no conditions.
+type Precondition struct {
+	// Version the item is expected to be at. Ignored when 0.
+	Version uint64
+	// Whether the item must not exist at the time of the write.
+	MustNotExist bool
+}
+
+// check verifies the precondition against the current state of an item. A version of 0
+// denotes an item which does not exist.
+func (cond Precondition) check(version uint64) error {
+	if cond.MustNotExist && version != 0 {
+		return ErrPreconditionFailed
+	}
+
+	if cond.Version != 0 && cond.Version != version {
+		return ErrPreconditionFailed
+	}
+
+	return nil
+}
+
+// Items are persisted as raw values in the items bucket of a Store. Everything we need to know
+// about them on top of that gets persisted as a fixed size record under the same key in the
+// meta bucket of the Store:
+//
+//   [0:8]  Version (uint64, BigEndian)
+//   [8:16] Expiry time (Unix nanoseconds, BigEndian). 0 if the item does not expire.
+//
+// Records persisted before expiry got introduced only contain the version.
+const metaLength = 16
+
+type itemMeta struct {
+	version   uint64
+	expiresAt int64
+}
+
+func decodeMeta(b []byte) itemMeta {
+	var meta itemMeta
+
+	if len(b) >= 8 {
+		meta.version = binary.BigEndian.Uint64(b[0:8])
+	}
+
+	if len(b) >= 16 {
+		meta.expiresAt = int64(binary.BigEndian.Uint64(b[8:16]))
+	}
+
+	return meta
+}
+
+func (meta itemMeta) encode() []byte {
+	b := make([]byte, metaLength)
+	binary.BigEndian.PutUint64(b[0:8], meta.version)
+	binary.BigEndian.PutUint64(b[8:16], uint64(meta.expiresAt))
+	return b
+}
+
+// expired reports whether the item has expired at the given time (in Unix nanoseconds).
+func (meta itemMeta) expired(now int64) bool {
+	return meta.expiresAt != 0 && meta.expiresAt <= now
+}
+
+// Expiring items are additionally indexed in the expiry bucket of a Store, keyed by their
+// expiry time followed by the item's key, so that they can be swept in order of expiry:
+//
+//   [0:8] Expiry time (Unix nanoseconds, BigEndian)
+//   [8:]  Item key
+func expiryKey(expiresAt int64, key []byte) []byte {
+	b := make([]byte, 8+len(key))
+	binary.BigEndian.PutUint64(b[0:8], uint64(expiresAt))
+	copy(b[8:], key)
+	return b
+}
+
+func decodeExpiryKey(b []byte) (int64, []byte) {
+	return int64(binary.BigEndian.Uint64(b[0:8])), b[8:]
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// Default rate limit of a Store in requests per second, and the number of requests it may burst
+// up to. Both can be overridden per Store.
+const RATE_LIMIT_DEFAULT = 100
+const RATE_BURST_DEFAULT = 100
+
+// rateLimiter is a token bucket. The zero value is a full bucket.
+type rateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take takes a token from the bucket, which refills at rate tokens per second up to burst tokens.
+// If the bucket is empty, returns false along with the time until the next token becomes available.
+func (limiter *rateLimiter) take(now time.Time, rate float64, burst float64) (bool, time.Duration) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if limiter.last.IsZero() {
+		limiter.tokens = burst
+	} else if elapsed := now.Sub(limiter.last); elapsed > 0 {
+		limiter.tokens += elapsed.Seconds() * rate
+		if limiter.tokens > burst {
+			limiter.tokens = burst
+		}
+	}
+
+	limiter.last = now
+
+	if limiter.tokens >= 1 {
+		limiter.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - limiter.tokens) / rate * float64(time.Second))
+}
+
+// Allow reports whether a request made at the given time is within the rate limit of the Store.
+// If it is not, the returned duration tells how long until the next request would be allowed.
+// Throttled requests get counted in the metrics of the Store.
+func (store *Store) Allow(now time.Time) (bool, time.Duration) {
+	settings := store.loadSettings()
+	rate, burst := settings.RateLimit, settings.RateBurst
+
+	if rate == 0 {
+		rate = RATE_LIMIT_DEFAULT
+	}
+
+	if burst == 0 {
+		burst = RATE_BURST_DEFAULT
+	}
+
+	ok, wait := store.limiter.take(now, float64(rate), float64(burst))
+	if !ok && store.metrics != nil {
+		store.metrics.IncThrottled()
+	}
+
+	return ok, wait
+}
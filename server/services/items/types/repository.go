@@ -4,49 +4,88 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
-
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/js13kgames/glitchd/server"
 	"github.com/js13kgames/glitchd/server/services/items/metrics"
+	"github.com/js13kgames/glitchd/server/services/items/storage"
 )
 
 const TOKEN_BYTES = 8
 const TOKEN_LENGTH = 2 * TOKEN_BYTES
 
-// StoreRepository is *not* thread-safe.
-// In our use case mutations only ever happen via administrative actions, at most a few times *daily*
-// and realistically never concurrently. In the edge case a store gets deleted during a read,
-// even if the read call came in before the delete call, the deletion *may* happen before, causing
-// the read request to fail to find the store and return with an error. If the read happens before
-// the deletion, the read call stack will continue to hold a pointer to the Store even if it meanwhile
-// gets removed from the Repository (which is fine).
-// The maps are allocated on the heap and will be touched by the GC. Realistically we expect at most
+var ErrStoreExists = errors.New("store with the given id or token already exists")
+
+// StoreRepository is safe for concurrent use.
+// Lookups are lock-free - they go through an immutable storeIndex which mutations replace as a whole,
+// so readers never block and never observe a partially applied mutation. Mutations only ever happen via
+// administrative actions, at most a few times *daily*, so they simply get serialized and rebuild the index
+// from scratch. In the edge case a store gets deleted during a read, even if the read call came in before
+// the delete call, the deletion *may* happen before, causing the read request to fail to find the store
+// and return with an error. If the read happens before the deletion, the read call stack will continue
+// to hold a pointer to the Store even if it meanwhile gets removed from the Repository (which is fine).
+// The index is allocated on the heap and will be touched by the GC. Realistically we expect at most
 // a few dozen up to a max of a few hundred stores to be present, so as long as this assumption holds
-// true GC pauses should not be impacted in a meaningful manner.
+// true neither GC pauses nor the copying on mutations should be impacted in a meaningful manner.
 type StoreRepository struct {
+	db        storage.Engine
+	bucketKey []byte
+	index     atomic.Value // *storeIndex
+	// Serializes mutations. Guards sequentialId and the exported fields of the Stores.
+	mu           sync.Mutex
+	sequentialId uint16
+}
+
+// storeIndex maps Stores by their access token and ID. Immutable once published.
+type storeIndex struct {
 	// Token -> Store (nearly all access is going to be reads identified by an access token, not an ID
 	// even though we primarily use the ID internally instead, to avoid coupling persisted data to a token
 	// which may change (as opposed to an ID which will not).
-	Items        map[string]*Store `json:"items"`
-	db           *bolt.DB          `json:"-"`
-	sequentialId uint16            `json:"-"`
-	bucketKey    []byte            `json:"-"`
-	ids          map[uint16]string `json:"-"` // ID -> Access token
+	tokens map[string]*Store
+	ids    map[uint16]*Store
+	stores []*Store // Ordered by ID.
+}
+
+func newStoreIndex(stores []*Store) *storeIndex {
+	index := &storeIndex{
+		tokens: make(map[string]*Store, len(stores)),
+		ids:    make(map[uint16]*Store, len(stores)),
+		stores: stores,
+	}
+
+	sort.Slice(stores, func(i, j int) bool {
+		return stores[i].Id < stores[j].Id
+	})
+
+	for _, store := range stores {
+		index.tokens[store.Token] = store
+		index.ids[store.Id] = store
+	}
+
+	return index
+}
+
+// persistedStore is the representation of a Store in the backing storage.
+type persistedStore struct {
+	Id uint16 `json:"id"`
+	StoreSettings
 }
 
 // LoadStoreRepository creates a StoreRepository and populates it from the given bucket identified
 // by its key in the backing storage.
-func LoadStoreRepository(db *bolt.DB, bucketKey []byte) (*StoreRepository, error) {
+func LoadStoreRepository(db storage.Engine, bucketKey []byte) (*StoreRepository, error) {
 	repository := &StoreRepository{
-		Items:     make(map[string]*Store),
 		db:        db,
-		ids:       make(map[uint16]string),
 		bucketKey: bucketKey,
 	}
 
-	if err := db.Update(func(tx *bolt.Tx) error {
+	var stores []*Store
+
+	if err := db.Update(func(tx storage.Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists(repository.bucketKey)
 		if err != nil {
 			return err
@@ -55,7 +94,7 @@ func LoadStoreRepository(db *bolt.DB, bucketKey []byte) (*StoreRepository, error
 		// Recreate all persisted Stores on the current database handle.
 		cur := bucket.Cursor()
 		for _, v := cur.First(); v != nil; _, v = cur.Next() {
-			var persisted *Store
+			var persisted persistedStore
 
 			// The structs are small and we really only expect a few hundred at most (see note on struct)
 			// so with this being performed only on startup, there is no reason to introduce a dependency
@@ -69,22 +108,38 @@ func LoadStoreRepository(db *bolt.DB, bucketKey []byte) (*StoreRepository, error
 			// store, because we need to pass in the db and have the store construct its bucket key
 			// (which does not get marshalled and persisted).
 			store := NewStore(persisted.Id, persisted.Token, db)
-			storeItemsBucket, err := tx.CreateBucketIfNotExists(store.bucketKey)
+			store.applySettings(persisted.StoreSettings)
+			storeBuckets, err := store.createBuckets(tx)
 			if err != nil {
 				return err
 			}
 
-			repository.putMemMap(store)
+			repository.trackId(store.Id)
+			stores = append(stores, store)
 
 			var length, size uint64
 
-			storeItemsCur := storeItemsBucket.Cursor()
-			for _, v := storeItemsCur.First(); v != nil; _, v = storeItemsCur.Next() {
+			storeItemsCur := storeBuckets.items.Cursor()
+			for k, v := storeItemsCur.First(); k != nil; k, v = storeItemsCur.Next() {
 				length++
 				size += uint64(len(v))
+
+				// Items persisted before versioning got introduced have no meta records. Backfill
+				// them so that every item has a version from here on.
+				if storeBuckets.meta.Get(k) == nil {
+					version, err := storeBuckets.items.NextSequence()
+					if err != nil {
+						return err
+					}
+
+					if err := storeBuckets.meta.Put(k, itemMeta{version: version}.encode()); err != nil {
+						return err
+					}
+				}
 			}
 
 			store.metrics = metrics.NewStoreAggregator(length, size)
+			store.watchers.reset(storeBuckets.items.Sequence())
 		}
 
 		return nil
@@ -92,6 +147,8 @@ func LoadStoreRepository(db *bolt.DB, bucketKey []byte) (*StoreRepository, error
 		return nil, err
 	}
 
+	repository.index.Store(newStoreIndex(stores))
+
 	return repository, nil
 }
 
@@ -101,94 +158,184 @@ func (repository *StoreRepository) RegisterMetricsTicks(tickManager server.TickM
 	tickManager.OnTickHour(repository.onTickHour)
 }
 
-//
-//
-//
+// GetByToken returns the Store the given access token belongs to, or nil if there is none.
+func (repository *StoreRepository) GetByToken(token string) *Store {
+	return repository.loadIndex().tokens[token]
+}
+
+// GetById returns the Store with the given ID, or nil if there is none.
 func (repository *StoreRepository) GetById(id uint16) *Store {
-	return repository.Items[repository.ids[id]]
+	return repository.loadIndex().ids[id]
 }
 
-//
-//
-//
+// All returns all Stores at the time of the call, ordered by ID. The slice is shared and must
+// not be modified.
+func (repository *StoreRepository) All() []*Store {
+	return repository.loadIndex().stores
+}
+
+// MarshalJSON marshals all Stores keyed by their access tokens.
+func (repository *StoreRepository) MarshalJSON() ([]byte, error) {
+	// The exported fields of the Stores may only be read with the mutex held.
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+
+	return json.Marshal(struct {
+		Items map[string]*Store `json:"items"`
+	}{
+		Items: repository.loadIndex().tokens,
+	})
+}
+
+// Create creates a Store. A zero id and an empty token get assigned automatically.
+// Returns ErrStoreExists if either of them is taken.
 func (repository *StoreRepository) Create(id uint16, token string) (*Store, error) {
-	store := NewStore(id, token, repository.db)
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
 
-	repository.assignKeysTo(store)
+	index := repository.loadIndex()
 
-	if err := repository.db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(store.bucketKey)
-		if err != nil {
+	if id == 0 {
+		id = repository.sequentialId + 1
+	}
+
+	if token == "" {
+		token = repository.genToken(index)
+	}
+
+	if index.ids[id] != nil || index.tokens[token] != nil {
+		return nil, ErrStoreExists
+	}
+
+	store := NewStore(id, token, repository.db)
+
+	if err := repository.db.Update(func(tx storage.Tx) error {
+		if _, err := store.createBuckets(tx); err != nil {
 			return err
 		}
 
 		store.metrics = metrics.NewStoreAggregator(0, 0)
 
-		return repository.write(store, tx)
+		return repository.write(tx, store.Id, store.StoreSettings)
 	}); err != nil {
 		return nil, err
 	}
 
-	repository.putMemMap(store)
+	repository.trackId(store.Id)
+	repository.publishIndex(append(repository.copyStores(index, nil), store))
 
 	return store, nil
 }
 
-//
-//
-//
-func (repository *StoreRepository) Save(store *Store) error {
-	repository.assignKeysTo(store)
+// Update applies the changes made by fn to the settings of the Store and persists them. fn gets
+// called with the mutex held and must not call back into the repository. An empty token keeps
+// the current one. Returns ErrStoreExists if the token is taken by another Store, and
+// ErrStoreDeleted if the Store is no longer in the repository. Nothing changes on errors.
+func (repository *StoreRepository) Update(store *Store, fn func(settings *StoreSettings)) error {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
 
-	if err := repository.db.Update(func(tx *bolt.Tx) error {
-		return repository.write(store, tx)
-	}); err != nil {
-		return err
+	settings := store.StoreSettings
+	fn(&settings)
+
+	if settings.Token == "" {
+		settings.Token = store.Token
 	}
 
-	repository.putMemMap(store)
+	return repository.update(store, settings)
+}
 
-	return nil
+// RotateToken assigns a new, random access token to the Store and returns it. The previous
+// token stops working immediately.
+func (repository *StoreRepository) RotateToken(store *Store) (string, error) {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+
+	settings := store.StoreSettings
+	settings.Token = repository.genToken(repository.loadIndex())
+
+	if err := repository.update(store, settings); err != nil {
+		return "", err
+	}
+
+	return settings.Token, nil
 }
 
-//
-//
-//
+// Delete deletes the Store along with all of its items. No-op if the Store does not exist.
 func (repository *StoreRepository) Delete(store *Store) error {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+
 	// Naive memory lookup to avoid hitting the backing store if we don't have the Store
-	// in memory to begin with. No-op if the Store does not exist.
-	if _, exists := repository.ids[store.Id]; !exists {
+	// in memory to begin with.
+	index := repository.loadIndex()
+	if index.ids[store.Id] != store {
 		return nil
 	}
 
-	if err := repository.db.Update(func(tx *bolt.Tx) error {
-		tx.DeleteBucket(store.bucketKey)
+	if err := repository.db.Update(func(tx storage.Tx) error {
+		store.deleteBuckets(tx)
 		return tx.Bucket(repository.bucketKey).Delete(storeIdToKey(store.Id))
 	}); err != nil {
 		return err
 	}
 
-	repository.delMemMap(store)
+	repository.publishIndex(repository.copyStores(index, store))
+	store.watchers.close()
 
 	return nil
 }
 
-//
-//
-//
-func (repository *StoreRepository) RotateToken(store *Store) error {
-	var currentToken string = store.Token
+// SweepExpired removes the items which have expired at the given time from all Stores.
+// Sweeping continues over the remaining Stores even if one of them fails, in which case the
+// first error encountered gets returned.
+func (repository *StoreRepository) SweepExpired(now time.Time) error {
+	var firstErr error
+
+	for _, store := range repository.All() {
+		for i := 0; i < SWEEP_BATCHES_MAX; i++ {
+			swept, err := store.Sweep(now, SWEEP_BATCH_LENGTH)
+			// Stores deleted since the sweep started have nothing left to sweep.
+			if err != nil {
+				if firstErr == nil && err != ErrStoreDeleted {
+					firstErr = err
+				}
+				break
+			}
+
+			if swept < SWEEP_BATCH_LENGTH {
+				break
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// update persists the given settings of the Store and applies them. The mutex must be held.
+func (repository *StoreRepository) update(store *Store, settings StoreSettings) error {
+	index := repository.loadIndex()
+	if index.ids[store.Id] != store {
+		return ErrStoreDeleted
+	}
 
-	store.Token = repository.genToken()
+	if other := index.tokens[settings.Token]; other != nil && other != store {
+		return ErrStoreExists
+	}
 
-	if err := repository.Save(store); err != nil {
-		// Roll back the change.
-		store.Token = currentToken
+	if err := repository.db.Update(func(tx storage.Tx) error {
+		return repository.write(tx, store.Id, settings)
+	}); err != nil {
 		return err
 	}
 
-	// Unmap the old named reference. The new token has been mapped in Save().
-	delete(repository.Items, store.Token)
+	tokenChanged := settings.Token != store.Token
+	store.applySettings(settings)
+
+	// Unmap the old token by rebuilding the index.
+	if tokenChanged {
+		repository.publishIndex(repository.copyStores(index, nil))
+	}
 
 	return nil
 }
@@ -196,55 +343,51 @@ func (repository *StoreRepository) RotateToken(store *Store) error {
 //
 //
 //
-func (repository *StoreRepository) write(store *Store, tx *bolt.Tx) error {
+func (repository *StoreRepository) write(tx storage.Tx, id uint16, settings StoreSettings) error {
 	bucket := tx.Bucket(repository.bucketKey)
 
-	data, err := json.Marshal(store)
+	data, err := json.Marshal(persistedStore{Id: id, StoreSettings: settings})
 	if err != nil {
 		return err
 	}
 
-	return bucket.Put(storeIdToKey(store.Id), data)
+	return bucket.Put(storeIdToKey(id), data)
 }
 
-//
-//
-//
-func (repository *StoreRepository) putMemMap(store *Store) {
-	// Auto-incrementing and without re-use, so let's account for potential unordered imports
-	// from external sources in the future.
-	if store.Id > repository.sequentialId {
-		repository.sequentialId = store.Id
-	}
-
-	repository.Items[store.Token] = store
-	repository.ids[store.Id] = store.Token
+func (repository *StoreRepository) loadIndex() *storeIndex {
+	return repository.index.Load().(*storeIndex)
 }
 
-//
-//
-//
-func (repository *StoreRepository) delMemMap(store *Store) {
-	delete(repository.ids, store.Id)
-	delete(repository.Items, store.Token)
+// publishIndex publishes a new index of the given Stores. The mutex must be held.
+func (repository *StoreRepository) publishIndex(stores []*Store) {
+	repository.index.Store(newStoreIndex(stores))
 }
 
-//
-//
-//
-func (repository *StoreRepository) assignKeysTo(store *Store) {
-	if store.Token == "" {
-		store.Token = repository.genToken()
+// copyStores returns a copy of the Stores in the index, without the excluded one (if any).
+func (repository *StoreRepository) copyStores(index *storeIndex, excluded *Store) []*Store {
+	stores := make([]*Store, 0, len(index.stores)+1)
+	for _, store := range index.stores {
+		if store != excluded {
+			stores = append(stores, store)
+		}
 	}
 
-	if store.Id == 0 {
-		store.Id = repository.sequentialId + 1
+	return stores
+}
+
+// trackId keeps track of the highest ID in use. The mutex must be held (or the repository
+// not yet shared).
+func (repository *StoreRepository) trackId(id uint16) {
+	// Auto-incrementing and without re-use, so let's account for potential unordered imports
+	// from external sources in the future.
+	if id > repository.sequentialId {
+		repository.sequentialId = id
 	}
 }
 
 // genToken generates a random, base16-encoded string that is unique
-// amongst the current tokens in this StoreRepository.
-func (repository *StoreRepository) genToken() string {
+// amongst the tokens in the given index.
+func (repository *StoreRepository) genToken(index *storeIndex) string {
 	src := make([]byte, TOKEN_BYTES)
 	rand.Read(src)
 	dst := make([]byte, hex.EncodedLen(len(src)))
@@ -254,32 +397,31 @@ func (repository *StoreRepository) genToken() string {
 	token := string(dst)
 
 	// On the off chance we get a collision, keep re-running until we get a unique.
-	if _, exists := repository.Items[token]; exists {
-		return repository.genToken()
+	if _, exists := index.tokens[token]; exists {
+		return repository.genToken(index)
 	}
 
 	return token
 }
 
-// @todo Obviously we don't want to iterate over a map each second. This will need restructuring
-// in how we reference the Stores internally. The seemingly more obvious solution of registering
-// the aggregators with a TickManager falters due to functions in Go not being comparable and thus
-// not having a reliable way of removing them when a Store gets deleted at runtime. Not without
-// using named references for all tickers anyways.
+// Ticks get forwarded to the Stores in the index at the time of the tick. The seemingly more obvious
+// solution of registering the aggregators with a TickManager falters due to functions in Go not being
+// comparable and thus not having a reliable way of removing them when a Store gets deleted at runtime.
+// Not without using named references for all tickers anyways.
 func (repository *StoreRepository) onTickSecond(tick time.Time) {
-	for _, store := range repository.Items {
+	for _, store := range repository.All() {
 		store.metrics.OnTickSecond(tick)
 	}
 }
 
 func (repository *StoreRepository) onTickMinute(tick time.Time) {
-	for _, store := range repository.Items {
+	for _, store := range repository.All() {
 		store.metrics.OnTickMinute(tick)
 	}
 }
 
 func (repository *StoreRepository) onTickHour(tick time.Time) {
-	for _, store := range repository.Items {
+	for _, store := range repository.All() {
 		store.metrics.OnTickHour(tick)
 	}
 }
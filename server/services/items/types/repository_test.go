@@ -0,0 +1,199 @@
+package types
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/js13kgames/glitchd/server/services/items/storage"
+)
+
+func openRepository(t *testing.T) *StoreRepository {
+	t.Helper()
+
+	stores, err := LoadStoreRepository(storage.NewMemory(), []byte("stores"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stores
+}
+
+// readConcurrently runs fn in a few goroutines until stop gets closed.
+func readConcurrently(stop <-chan struct{}, wg *sync.WaitGroup, fn func()) {
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					fn()
+				}
+			}
+		}()
+	}
+}
+
+func TestRepositoryRotateTokenDuringReads(t *testing.T) {
+	stores := openRepository(t)
+
+	store, err := stores.Create(0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		token = atomic.Value{}
+		stop  = make(chan struct{})
+		wg    sync.WaitGroup
+	)
+
+	token.Store(store.Token)
+
+	readConcurrently(stop, &wg, func() {
+		// The token read may be stale by the time of the lookup, but a Store found by it must
+		// always be the rotated one.
+		if found := stores.GetByToken(token.Load().(string)); found != nil && found != store {
+			t.Error("token mapped to the wrong store")
+		}
+
+		if stores.GetById(store.Id) != store {
+			t.Error("store not found by its id")
+		}
+
+		if _, err := store.Put("foo", []byte("bar"), 0, Precondition{}); err != nil {
+			t.Error(err)
+		}
+
+		store.Allow(time.Now())
+		stores.onTickSecond(time.Now())
+	})
+
+	previous := []string{store.Token}
+	for i := 0; i < 50; i++ {
+		next, err := stores.RotateToken(store)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token.Store(next)
+		previous = append(previous, next)
+	}
+
+	close(stop)
+	wg.Wait()
+
+	current := previous[len(previous)-1]
+	for _, old := range previous[:len(previous)-1] {
+		if stores.GetByToken(old) != nil {
+			t.Fatalf("expected previous token %s to be unmapped", old)
+		}
+	}
+
+	if stores.GetByToken(current) != store {
+		t.Fatal("expected the current token to map to the store")
+	}
+}
+
+func TestRepositoryDeleteDuringReads(t *testing.T) {
+	stores := openRepository(t)
+
+	var created []*Store
+	for i := 0; i < 20; i++ {
+		store, err := stores.Create(0, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, store)
+	}
+
+	var (
+		stop = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+
+	readConcurrently(stop, &wg, func() {
+		for _, store := range created {
+			if found := stores.GetByToken(store.loadSettings().Token); found != nil && found != store {
+				t.Error("token mapped to the wrong store")
+			}
+		}
+
+		for _, store := range stores.All() {
+			store.Metrics()
+		}
+
+		if err := stores.SweepExpired(time.Now()); err != nil {
+			t.Error(err)
+		}
+
+		if _, err := stores.MarshalJSON(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	for i, store := range created {
+		if i%2 == 0 {
+			if err := stores.Delete(store); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		if err := stores.Update(store, func(settings *StoreSettings) { settings.MaxItems = 10 }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+
+	if n := len(stores.All()); n != len(created)/2 {
+		t.Fatalf("expected %d stores, got %d", len(created)/2, n)
+	}
+
+	for i, store := range created {
+		if deleted := i%2 == 0; deleted != (stores.GetById(store.Id) == nil) {
+			t.Fatalf("store %d: expected deleted to be %t", store.Id, deleted)
+		}
+	}
+
+	if err := stores.Update(created[0], func(settings *StoreSettings) {}); err != ErrStoreDeleted {
+		t.Fatalf("expected ErrStoreDeleted, got %v", err)
+	}
+}
+
+func TestRepositoryTokenConflicts(t *testing.T) {
+	stores := openRepository(t)
+
+	a, err := stores.Create(0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := stores.Create(0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stores.Create(0, a.Token); err != ErrStoreExists {
+		t.Fatalf("expected ErrStoreExists, got %v", err)
+	}
+
+	if _, err := stores.Create(a.Id, ""); err != ErrStoreExists {
+		t.Fatalf("expected ErrStoreExists, got %v", err)
+	}
+
+	token := b.Token
+	if err := stores.Update(b, func(settings *StoreSettings) { settings.Token = a.Token }); err != ErrStoreExists {
+		t.Fatalf("expected ErrStoreExists, got %v", err)
+	}
+
+	if b.Token != token || stores.GetByToken(token) != b {
+		t.Fatal("expected a failed update to leave the store unchanged")
+	}
+}
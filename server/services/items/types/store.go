@@ -1,114 +1,718 @@
 package types
 
 import (
+	"bytes"
+	"errors"
+	"sort"
 	"strconv"
+	"sync/atomic"
+	"time"
 
-	"github.com/boltdb/bolt"
+	globalMetrics "github.com/js13kgames/glitchd/server/metrics"
 	"github.com/js13kgames/glitchd/server/services/items/metrics"
+	"github.com/js13kgames/glitchd/server/services/items/storage"
+)
+
+const SCAN_LIMIT_DEFAULT = 100
+const SCAN_LIMIT_MAX = 1000
+
+// Number of the largest items reported in the stats of a Store.
+const LARGEST_ITEMS_REPORTED = 10
+
+// Max total size of the values returned in a single page of a Scan. Pages get cut short once
+// it's reached, so that scanning with values can't produce arbitrarily large responses.
+const SCAN_SIZE_MAX = 2 * 1024 * 1024
+
+// Max size of a single item's key and value. Batches may carry up to BATCH_LENGTH_MAX items of
+// this size. Keys are capped well below the max key size of bolt, as they also get retained in memory
+// by watchers.
+const KEY_SIZE_MAX = 1024
+const ITEM_SIZE_MAX = 32 * 1024
+const BATCH_LENGTH_MAX = 64
+
+// Expired items get swept in batches of up to SWEEP_BATCH_LENGTH items per transaction and
+// at most SWEEP_BATCHES_MAX batches per Store per sweep, so that a single Store with lots
+// of expired items can't hog the backing store. The remainder gets swept on later runs.
+const SWEEP_BATCH_LENGTH = 256
+const SWEEP_BATCHES_MAX = 16
+
+var (
+	ErrItemTooLarge  = errors.New("item exceeds the max item size")
+	ErrBatchTooLarge = errors.New("batch exceeds the max batch length")
+	ErrQuotaExceeded = errors.New("write exceeds the quota of the store")
 )
 
 type Store struct {
-	Id           uint16 `json:"id"`
+	Id uint16 `json:"id"`
+	StoreSettings
+
+	db              storage.Engine           `json:"-"`
+	bucketKey       []byte                   `json:"-"`
+	metaBucketKey   []byte                   `json:"-"`
+	expiryBucketKey []byte                   `json:"-"`
+	metrics         *metrics.StoreAggregator `json:"-"`
+	watchers        *watchHub                `json:"-"`
+	limiter         rateLimiter              `json:"-"`
+	// Copy of the settings applied to requests (*StoreSettings). Gets swapped as a whole, so that requests
+	// never see a partially applied change and don't race with the StoreRepository mutating the settings.
+	settings atomic.Value `json:"-"`
+}
+
+// StoreSettings are the persisted fields of a Store which can be changed after its creation.
+// Changes need to go through StoreRepository.Update.
+type StoreSettings struct {
 	Token        string `json:"token"`
 	OwnerId      uint64 `json:"ownerId" binding:"required"`
 	SubmissionId uint64 `json:"submissionId"`
-
-	db        *bolt.DB                 `json:"-"`
-	bucketKey []byte                   `json:"-"`
-	metrics   *metrics.StoreAggregator `json:"-"`
+	// Quotas on the number of items and their total size (of values) in bytes. 0 = unlimited.
+	MaxItems uint64 `json:"maxItems"`
+	MaxSize  uint64 `json:"maxSize"`
+	// Rate limit overrides, in requests per second and the number of requests which may be
+	// made in a burst. 0 = RATE_LIMIT_DEFAULT and RATE_BURST_DEFAULT respectively.
+	RateLimit uint32 `json:"rateLimit"`
+	RateBurst uint32 `json:"rateBurst"`
 }
 
-func NewStore(id uint16, token string, db *bolt.DB) *Store {
-	return &Store{
-		Id:        id,
-		Token:     token,
-		db:        db,
-		bucketKey: []byte("stores." + strconv.FormatUint(uint64(id), 10) + ".items"),
+func NewStore(id uint16, token string, db storage.Engine) *Store {
+	prefix := "stores." + strconv.FormatUint(uint64(id), 10)
+
+	store := &Store{
+		Id:              id,
+		db:              db,
+		bucketKey:       []byte(prefix + ".items"),
+		metaBucketKey:   []byte(prefix + ".meta"),
+		expiryBucketKey: []byte(prefix + ".expiry"),
+		watchers:        newWatchHub(),
 	}
+
+	store.applySettings(StoreSettings{Token: token})
+
+	return store
 }
 
-//
-//
-//
-func (store *Store) Get(key string) ([]byte, error) {
+// applySettings replaces the settings of the Store. Only the StoreRepository may call it on
+// Stores it manages (with its mutex held), as the exported fields are not synchronized.
+func (store *Store) applySettings(settings StoreSettings) {
+	store.StoreSettings = settings
+	store.settings.Store(&settings)
+}
+
+// loadSettings returns the settings currently applied to requests. Must not be modified.
+func (store *Store) loadSettings() *StoreSettings {
+	return store.settings.Load().(*StoreSettings)
+}
+
+// Get retrieves the value of the item along with its version. The value is nil (and the
+// version 0) if the item does not exist or has expired.
+func (store *Store) Get(key string) ([]byte, uint64, error) {
 	// @todo Pre-allocate. We'll need item keys mapped in-memory to sizes first though.
-	var value []byte
+	var (
+		value   []byte
+		version uint64
+	)
+
+	keyBytes := []byte(key)
+	if err := checkKey(keyBytes); err != nil {
+		return nil, 0, err
+	}
 
 	// Increment metrics even if the given key ends up not being found.
 	if store.metrics != nil {
 		store.metrics.IncReads()
+		store.metrics.TrackKey(key)
 	}
 
-	err := store.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(store.bucketKey)
+	err := store.db.View(func(tx storage.Tx) error {
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		if v := b.items.Get(keyBytes); v != nil {
+			meta := decodeMeta(b.meta.Get(keyBytes))
+			if meta.expired(time.Now().UnixNano()) {
+				return nil
+			}
 
-		if v := bucket.Get([]byte(key)); v != nil {
 			value = make([]byte, len(v))
 			copy(value, v)
+			version = meta.version
 		}
 
 		return nil
 	})
 
-	return value, err
+	return value, version, err
 }
 
-// @todo Keep monitoring metrics and batch writes via a WAL if need be.
-// BoltDB is slow on random writes which "should" not matter in our case, but "should"
-// is not a confident assumption.
-func (store *Store) Put(key string, value []byte) error {
-	keyBytes := []byte(key)
-	return store.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(store.bucketKey)
-
-		// @todo With metrics on, this is an additional read per write hitting the backing store.
-		// Benchmark doing the size and len counting on-demand for a relatively large dataset (100k keys at 32KiB)
-		if store.metrics != nil {
-			var (
-				lengthDelta uint64
-				sizeDelta   uint64
-				sizeItemNew int = len(value)
-			)
-
-			if v := bucket.Get(keyBytes); v != nil {
-				sizeItemOld := len(v)
-				sizeDiff := sizeItemNew - sizeItemOld
-
-				if sizeDiff >= 0 {
-					sizeDelta = uint64(sizeDiff)
-				} else {
-					sizeDelta = ^uint64(sizeDiff - 1)
+// BoltDB is slow on random writes, so writes go through Engine.Batch and get group committed
+// if enabled (see storage.GroupCommit). As batched transactions may be re-run, writes start
+// each run with a fresh writeDelta.
+//
+// Put stores the item if it satisfies the given Precondition and returns the version it
+// has been stored at. The item expires after the given ttl, unless it is 0.
+func (store *Store) Put(key string, value []byte, ttl time.Duration, cond Precondition) (uint64, error) {
+	var (
+		delta   writeDelta
+		version uint64
+	)
+
+	if err := store.db.Batch(func(tx storage.Tx) (err error) {
+		delta = writeDelta{}
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		version, err = store.put(b, []byte(key), value, ttl, cond, &delta)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	store.applyDelta(&delta)
+
+	if store.metrics != nil {
+		store.metrics.TrackKey(key)
+	}
+
+	return version, nil
+}
+
+// Delete removes the item if it satisfies the given Precondition. Deleting an item which
+// does not exist is a no-op, unless a specific version was expected.
+func (store *Store) Delete(key string, cond Precondition) error {
+	var delta writeDelta
+
+	if err := store.db.Batch(func(tx storage.Tx) error {
+		delta = writeDelta{}
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		return store.delete(b, []byte(key), cond, &delta)
+	}); err != nil {
+		return err
+	}
+
+	store.applyDelta(&delta)
+
+	return nil
+}
+
+// BatchGet retrieves the items for the given keys in a single read transaction. Keys which have
+// no value set (or have expired) are omitted from the result, otherwise the order of the keys
+// is retained.
+func (store *Store) BatchGet(keys []string) ([]Item, error) {
+	if len(keys) > BATCH_LENGTH_MAX {
+		return nil, ErrBatchTooLarge
+	}
+
+	for _, key := range keys {
+		if err := checkKey([]byte(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]Item, 0, len(keys))
+
+	if store.metrics != nil {
+		store.metrics.AddReads(uint32(len(keys)))
+	}
+
+	err := store.db.View(func(tx storage.Tx) error {
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixNano()
+
+		for _, key := range keys {
+			keyBytes := []byte(key)
+
+			if v := b.items.Get(keyBytes); v != nil {
+				meta := decodeMeta(b.meta.Get(keyBytes))
+				if meta.expired(now) {
+					continue
 				}
-			} else {
-				lengthDelta = 1
-				sizeDelta = uint64(sizeItemNew)
+
+				value := make([]byte, len(v))
+				copy(value, v)
+				items = append(items, Item{Key: key, Value: value, Version: meta.version})
 			}
+		}
 
-			store.metrics.IncWrites(lengthDelta, sizeDelta)
+		return nil
+	})
+
+	return items, err
+}
+
+// BatchPut stores all given items atomically - either all of them get stored, or none do.
+// Items with a non-zero Version only get stored if they are still at that version - otherwise
+// the whole batch fails with ErrPreconditionFailed. Returns the versions the items have been
+// stored at, in the order of the items. The items do not expire.
+func (store *Store) BatchPut(items []Item) ([]uint64, error) {
+	if len(items) > BATCH_LENGTH_MAX {
+		return nil, ErrBatchTooLarge
+	}
+
+	var (
+		delta    writeDelta
+		versions = make([]uint64, len(items))
+	)
+
+	if err := store.db.Batch(func(tx storage.Tx) (err error) {
+		delta = writeDelta{}
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		for i, item := range items {
+			if versions[i], err = store.put(b, []byte(item.Key), item.Value, 0, Precondition{Version: item.Version}, &delta); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	store.applyDelta(&delta)
+
+	return versions, nil
+}
+
+// BatchDelete deletes all given keys atomically. Keys which are not set are skipped.
+func (store *Store) BatchDelete(keys []string) error {
+	if len(keys) > BATCH_LENGTH_MAX {
+		return ErrBatchTooLarge
+	}
+
+	var delta writeDelta
+
+	if err := store.db.Batch(func(tx storage.Tx) error {
+		delta = writeDelta{}
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := store.delete(b, []byte(key), Precondition{}, &delta); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	store.applyDelta(&delta)
+
+	return nil
+}
+
+// writeDelta accumulates the side effects of a write transaction - changes to the metrics of
+// a Store and the events for its watchers. It only gets applied once the transaction commits,
+// so that rolled back (or re-run) writes neither skew the metrics nor reach the watchers. Negative
+// deltas are stored in two's complement, as the aggregator expects.
+type writeDelta struct {
+	writes uint32
+	length uint64
+	size   uint64
+	events []Event
+}
+
+// Sweep removes up to limit items which have expired at the given time, in a single transaction.
+// Returns the number of expiry index entries processed - which includes stale entries that got
+// dropped without removing anything. Fewer than limit means there is nothing left to sweep.
+// Sweeping does not count as writes in the metrics.
+func (store *Store) Sweep(now time.Time, limit int) (int, error) {
+	var (
+		delta   writeDelta
+		expired [][]byte
+	)
+
+	if err := store.db.Update(func(tx storage.Tx) error {
+		delta = writeDelta{}
+		expired = expired[:0]
+
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
 		}
 
-		return bucket.Put(keyBytes, value)
+		nowNano := now.UnixNano()
+
+		// Collect first, remove afterwards - mutating the bucket while a cursor walks it
+		// could make the cursor skip entries.
+		cur := b.expiry.Cursor()
+		for k, _ := cur.First(); k != nil && len(expired) < limit; k, _ = cur.Next() {
+			if expiresAt, _ := decodeExpiryKey(k); expiresAt > nowNano {
+				break
+			}
+
+			expired = append(expired, append([]byte(nil), k...))
+		}
+
+		for _, k := range expired {
+			expiresAt, key := decodeExpiryKey(k)
+
+			v := b.items.Get(key)
+			meta := decodeMeta(b.meta.Get(key))
+
+			// Stale index entries (of items which got overwritten or deleted in the meantime)
+			// are simply dropped.
+			if v == nil || meta.expiresAt != expiresAt {
+				if err := b.expiry.Delete(k); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := store.remove(b, key, v, meta, &delta); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	delta.writes = 0
+	store.applyDelta(&delta)
+
+	return len(expired), nil
+}
+
+// storeBuckets groups the buckets of a Store within a single transaction.
+type storeBuckets struct {
+	items  storage.Bucket
+	meta   storage.Bucket
+	expiry storage.Bucket
+}
+
+// buckets returns the buckets of the Store within the transaction. Returns ErrStoreDeleted if they
+// are gone, which callers holding on to a Store while it gets deleted may run into.
+func (store *Store) buckets(tx storage.Tx) (*storeBuckets, error) {
+	b := &storeBuckets{
+		items:  tx.Bucket(store.bucketKey),
+		meta:   tx.Bucket(store.metaBucketKey),
+		expiry: tx.Bucket(store.expiryBucketKey),
+	}
+
+	if b.items == nil || b.meta == nil || b.expiry == nil {
+		return nil, ErrStoreDeleted
+	}
+
+	return b, nil
+}
+
+// createBuckets creates the buckets of the Store, unless they already exist.
+func (store *Store) createBuckets(tx storage.Tx) (*storeBuckets, error) {
+	var (
+		b   storeBuckets
+		err error
+	)
+
+	if b.items, err = tx.CreateBucketIfNotExists(store.bucketKey); err != nil {
+		return nil, err
+	}
+
+	if b.meta, err = tx.CreateBucketIfNotExists(store.metaBucketKey); err != nil {
+		return nil, err
+	}
+
+	if b.expiry, err = tx.CreateBucketIfNotExists(store.expiryBucketKey); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// deleteBuckets deletes the buckets of the Store along with all items within them.
+func (store *Store) deleteBuckets(tx storage.Tx) {
+	tx.DeleteBucket(store.bucketKey)
+	tx.DeleteBucket(store.metaBucketKey)
+	tx.DeleteBucket(store.expiryBucketKey)
+}
+
+// put stores the item within an already opened write transaction and returns its new version.
+// Versions are drawn from the sequence of the items bucket, so they increase monotonically
+// across the whole Store and never get reused - not even after an item gets deleted.
+func (store *Store) put(b *storeBuckets, key []byte, value []byte, ttl time.Duration, cond Precondition, delta *writeDelta) (uint64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+
+	if len(value) > ITEM_SIZE_MAX {
+		return 0, ErrItemTooLarge
+	}
+
+	var (
+		now  = time.Now().UnixNano()
+		prev itemMeta
+	)
+
+	v := b.items.Get(key)
+	if v != nil {
+		prev = decodeMeta(b.meta.Get(key))
+	}
+
+	// Expired items which have not been swept yet are considered gone already.
+	current := prev.version
+	if prev.expired(now) {
+		current = 0
+	}
+
+	if err := cond.check(current); err != nil {
+		return 0, err
+	}
+
+	// @todo With metrics on, this is an additional read per write hitting the backing store.
+	// Benchmark doing the size and len counting on-demand for a relatively large dataset (100k keys at 32KiB)
+	if store.metrics != nil {
+		var (
+			lengthDelta uint64
+			sizeDelta   = uint64(len(value))
+		)
+
+		if v != nil {
+			sizeDelta = uint64(len(value) - len(v))
+		} else {
+			lengthDelta = 1
+		}
+
+		if err := store.checkQuota(lengthDelta, sizeDelta, delta); err != nil {
+			return 0, err
+		}
+
+		delta.writes++
+		delta.length += lengthDelta
+		delta.size += sizeDelta
+	}
+
+	version, err := b.items.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := b.items.Put(key, value); err != nil {
+		return 0, err
+	}
+
+	delta.events = append(delta.events, Event{
+		Type:     EventPut,
+		Key:      string(key),
+		Value:    value,
+		Revision: version,
 	})
+
+	next := itemMeta{version: version}
+	if ttl > 0 {
+		next.expiresAt = now + int64(ttl)
+	}
+
+	return version, store.putMeta(b, key, prev, next)
 }
 
-//
-//
-//
-func (store *Store) Delete(key string) error {
-	keyBytes := []byte(key)
+// checkQuota verifies that growing the Store by the given length and size - on top of the changes
+// already pending in the transaction - keeps it within its quotas. Writes which do not grow the
+// Store always pass, so that Stores over their quotas (eg. after lowering them) can be shrunk.
+// Note: Deltas get applied to the metrics only after their transaction commits, so concurrent
+// writes (including those sharing a group commit) may overshoot the quotas by a few items.
+func (store *Store) checkQuota(lengthDelta, sizeDelta uint64, delta *writeDelta) error {
+	settings := store.loadSettings()
+
+	if settings.MaxItems != 0 && lengthDelta != 0 && store.metrics.Length()+delta.length+lengthDelta > settings.MaxItems {
+		return ErrQuotaExceeded
+	}
+
+	if settings.MaxSize != 0 && int64(sizeDelta) > 0 && store.metrics.Size()+delta.size+sizeDelta > settings.MaxSize {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// putMeta replaces the meta record of an item, keeping the expiry index in sync.
+func (store *Store) putMeta(b *storeBuckets, key []byte, prev, next itemMeta) error {
+	if prev.expiresAt != 0 && prev.expiresAt != next.expiresAt {
+		if err := b.expiry.Delete(expiryKey(prev.expiresAt, key)); err != nil {
+			return err
+		}
+	}
+
+	if next.expiresAt != 0 {
+		if err := b.expiry.Put(expiryKey(next.expiresAt, key), []byte{}); err != nil {
+			return err
+		}
+	}
+
+	return b.meta.Put(key, next.encode())
+}
+
+// delete removes the item within an already opened write transaction.
+func (store *Store) delete(b *storeBuckets, key []byte, cond Precondition, delta *writeDelta) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	v := b.items.Get(key)
+	if v == nil {
+		// Expecting a specific version of an item which does not exist is a mismatch as well.
+		return cond.check(0)
+	}
+
+	meta := decodeMeta(b.meta.Get(key))
+
+	current := meta.version
+	if meta.expired(time.Now().UnixNano()) {
+		current = 0
+	}
+
+	if err := cond.check(current); err != nil {
+		return err
+	}
+
+	return store.remove(b, key, v, meta, delta)
+}
+
+// remove deletes the item along with its meta record and expiry index entry, without any checks.
+func (store *Store) remove(b *storeBuckets, key []byte, v []byte, meta itemMeta, delta *writeDelta) error {
+	revision, err := b.items.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	delta.events = append(delta.events, Event{
+		Type:     EventDelete,
+		Key:      string(key),
+		Revision: revision,
+	})
+
+	if store.metrics != nil {
+		// Decrement length by 1 and size by len(v).
+		delta.writes++
+		delta.length += ^uint64(0)
+		delta.size += ^uint64(len(v) - 1)
+	}
+
+	if meta.expiresAt != 0 {
+		if err := b.expiry.Delete(expiryKey(meta.expiresAt, key)); err != nil {
+			return err
+		}
+	}
+
+	if err := b.items.Delete(key); err != nil {
+		return err
+	}
+
+	return b.meta.Delete(key)
+}
+
+func (store *Store) applyDelta(delta *writeDelta) {
+	if store.metrics != nil && (delta.writes != 0 || delta.length != 0 || delta.size != 0) {
+		store.metrics.AddWrites(delta.writes, delta.length, delta.size)
+	}
+
+	if len(delta.events) != 0 {
+		store.watchers.publish(delta.events)
+	}
+}
+
+// Watch subscribes to changes of the item under the given key - or of all items with keys
+// starting with it, if prefix is set. Events after the given revision which are still retained
+// get replayed first (without values), so that watchers can resume without missing changes.
+// A revision of 0 only subscribes to new events. Expired items get reported as deleted once
+// they get swept.
+func (store *Store) Watch(key string, prefix bool, after uint64) (*Watcher, error) {
+	return store.watchers.subscribe(key, prefix, after)
+}
+
+// ScanOptions narrows down a Store.Scan. Prefix and the [Start, End) range combine, with empty
+// values leaving the respective bound open.
+type ScanOptions struct {
+	Prefix string
+	Start  string
+	End    string
+	Limit  int
+	Values bool
+}
 
-	return store.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(store.bucketKey)
+// Scan walks the items in key order and returns at most Limit of them (capped to SCAN_LIMIT_MAX),
+// along with the key to resume from on the next page. The returned key is empty when the walk
+// has been exhausted. With Values set, pages also get cut short once their values would exceed
+// SCAN_SIZE_MAX in total.
+func (store *Store) Scan(opts ScanOptions) ([]Item, string, error) {
+	var (
+		items  []Item
+		next   string
+		size   int
+		prefix = []byte(opts.Prefix)
+		start  = []byte(opts.Start)
+		end    = []byte(opts.End)
+		limit  = opts.Limit
+	)
 
-		if store.metrics != nil {
-			if v := bucket.Get(keyBytes); v != nil {
-				// Decrement length by 1 and size by len(v).
-				store.metrics.IncWrites(^uint64(0), ^uint64(len(v)-1))
+	if limit <= 0 {
+		limit = SCAN_LIMIT_DEFAULT
+	} else if limit > SCAN_LIMIT_MAX {
+		limit = SCAN_LIMIT_MAX
+	}
+
+	// Everything preceding the prefix can be skipped outright.
+	if bytes.Compare(prefix, start) > 0 {
+		start = prefix
+	}
+
+	if store.metrics != nil {
+		store.metrics.IncReads()
+	}
+
+	err := store.db.View(func(tx storage.Tx) error {
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+		cur := b.items.Cursor()
+		now := time.Now().UnixNano()
+
+		for k, v := cur.Seek(start); k != nil; k, v = cur.Next() {
+			if !bytes.HasPrefix(k, prefix) || (len(end) != 0 && bytes.Compare(k, end) >= 0) {
+				break
+			}
+
+			meta := decodeMeta(b.meta.Get(k))
+			if meta.expired(now) {
+				continue
+			}
+
+			if len(items) == limit || (opts.Values && len(items) != 0 && size+len(v) > SCAN_SIZE_MAX) {
+				next = string(k)
+				break
+			}
+
+			item := Item{Key: string(k), Version: meta.version}
+			if opts.Values {
+				size += len(v)
+				item.Value = make([]byte, len(v))
+				copy(item.Value, v)
 			}
+
+			items = append(items, item)
 		}
 
-		return bucket.Delete(keyBytes)
+		return nil
 	})
+
+	return items, next, err
 }
 
 //
@@ -121,3 +725,82 @@ func (store *Store) Metrics() *metrics.StoreSnapshot {
 
 	return nil
 }
+
+// StoreStats extends the metrics of a Store with stats which need to be read from its items.
+type StoreStats struct {
+	*metrics.StoreSnapshot
+	Largest []ItemSize `json:"largest"`
+}
+
+type ItemSize struct {
+	Key  string `json:"key"`
+	Size int    `json:"size"`
+}
+
+// Stats returns the metrics of the Store along with its largest items, largest first. Finding
+// those requires a pass over all items, so this is meant for occasional inspection only.
+func (store *Store) Stats() (*StoreStats, error) {
+	if store.metrics == nil {
+		return nil, nil
+	}
+
+	// Ascending by size, so that the smallest of the candidates is the first to be replaced.
+	largest := make([]ItemSize, 0, LARGEST_ITEMS_REPORTED)
+
+	err := store.db.View(func(tx storage.Tx) error {
+		b, err := store.buckets(tx)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixNano()
+
+		return b.items.ForEach(func(k, v []byte) error {
+			if len(largest) == LARGEST_ITEMS_REPORTED && len(v) <= largest[0].Size {
+				return nil
+			}
+
+			if decodeMeta(b.meta.Get(k)).expired(now) {
+				return nil
+			}
+
+			i := sort.Search(len(largest), func(i int) bool {
+				return largest[i].Size >= len(v)
+			})
+
+			item := ItemSize{string(k), len(v)}
+			if len(largest) < LARGEST_ITEMS_REPORTED {
+				largest = append(largest, ItemSize{})
+				copy(largest[i+1:], largest[i:])
+				largest[i] = item
+			} else {
+				// Full - drop the smallest by shifting everything below the insertion point down.
+				copy(largest, largest[1:i])
+				largest[i-1] = item
+			}
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(largest)-1; i < j; i, j = i+1, j-1 {
+		largest[i], largest[j] = largest[j], largest[i]
+	}
+
+	return &StoreStats{
+		StoreSnapshot: store.metrics.Collect(),
+		Largest:       largest,
+	}, nil
+}
+
+// MetricsHistory returns the recorded history of the metrics of the Store within the given time range.
+func (store *Store) MetricsHistory(from, to time.Time, resolution globalMetrics.Resolution) *globalMetrics.SeriesSnapshot {
+	if store.metrics != nil {
+		return store.metrics.History(from, to, resolution)
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+)
+
+// Number of most recent events retained per Store for watchers resuming from a past revision.
+// Only the key, type and revision of each event get retained - replayed puts carry no value.
+const WATCH_HISTORY_LENGTH = 1024
+
+// Number of events a watcher may lag behind before it gets dropped.
+const WATCH_BUFFER_LENGTH = 256
+
+var (
+	ErrRevisionCompacted = errors.New("events after the requested revision are no longer retained")
+	ErrWatcherLagged     = errors.New("watcher fell too far behind")
+	ErrStoreDeleted      = errors.New("store has been deleted")
+)
+
+type EventType uint8
+
+const (
+	EventPut EventType = iota
+	EventDelete
+)
+
+// Event describes a single change to an item. Every write to a Store gets its own revision,
+// drawn from the same sequence as item versions - for puts, the revision is the new version.
+// Value is only set for puts delivered live. Replayed puts leave it nil, so the item has to be
+// re-read if its value is needed.
+type Event struct {
+	Type     EventType
+	Key      string
+	Value    []byte
+	Revision uint64
+}
+
+//
+//
+//
+type Watcher struct {
+	hub    *watchHub
+	key    []byte
+	prefix bool
+	events chan Event
+	err    error
+}
+
+// Events returns the channel events get delivered on. The channel gets closed once the
+// watcher closes, at which point Err tells whether that was due to an error.
+func (watcher *Watcher) Events() <-chan Event {
+	return watcher.events
+}
+
+func (watcher *Watcher) Err() error {
+	watcher.hub.mu.Lock()
+	defer watcher.hub.mu.Unlock()
+
+	return watcher.err
+}
+
+// Close unsubscribes the watcher. Safe to call multiple times.
+func (watcher *Watcher) Close() {
+	watcher.hub.mu.Lock()
+	watcher.hub.unsubscribe(watcher, nil)
+	watcher.hub.mu.Unlock()
+}
+
+func (watcher *Watcher) matches(key string) bool {
+	if watcher.prefix {
+		return bytes.HasPrefix([]byte(key), watcher.key)
+	}
+
+	return key == string(watcher.key)
+}
+
+// watchHub fans out the events of a single Store to its watchers.
+// Events get published once their transaction commits. Since commits and publishing are not
+// atomic, concurrent writes may get published out of order - the hub buffers them until
+// their revisions are contiguous again, so watchers always observe events in revision order.
+// History only gets retained once the Store got watched for the first time - Stores nobody
+// watches don't pay for it.
+type watchHub struct {
+	mu       sync.Mutex
+	next     uint64
+	first    uint64
+	history  *eventRing
+	pending  map[uint64]Event
+	watchers map[*Watcher]struct{}
+	closed   bool
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{
+		next:     1,
+		first:    1,
+		pending:  make(map[uint64]Event),
+		watchers: make(map[*Watcher]struct{}),
+	}
+}
+
+// reset sets the revision the Store is at. Events up to and including it are considered
+// to be in the past and can not be resumed from.
+func (hub *watchHub) reset(revision uint64) {
+	hub.mu.Lock()
+	hub.next = revision + 1
+	hub.first = hub.next
+	hub.mu.Unlock()
+}
+
+//
+//
+//
+func (hub *watchHub) publish(events []Event) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for _, ev := range events {
+		// Revisions below next have either been delivered already or been skipped over
+		// by the safety valve below. Either way it's too late for them.
+		if ev.Revision < hub.next {
+			continue
+		}
+
+		hub.pending[ev.Revision] = ev
+	}
+
+	hub.flush()
+
+	// Safety valve. Revisions are expected to be contiguous as every revision drawn in a committed
+	// transaction gets published. Should that ever not hold, skip the gap instead of stalling.
+	if len(hub.pending) > WATCH_BUFFER_LENGTH {
+		min := ^uint64(0)
+		for revision := range hub.pending {
+			if revision < min {
+				min = revision
+			}
+		}
+
+		hub.next = min
+		hub.flush()
+	}
+}
+
+func (hub *watchHub) flush() {
+	for {
+		ev, ok := hub.pending[hub.next]
+		if !ok {
+			return
+		}
+
+		delete(hub.pending, hub.next)
+		hub.next++
+
+		if hub.history != nil {
+			hub.history.push(Event{Type: ev.Type, Key: ev.Key, Revision: ev.Revision})
+		}
+
+		for watcher := range hub.watchers {
+			if !watcher.matches(ev.Key) {
+				continue
+			}
+
+			select {
+			case watcher.events <- ev:
+			default:
+				hub.unsubscribe(watcher, ErrWatcherLagged)
+			}
+		}
+	}
+}
+
+// subscribe registers a watcher for the given key (or prefix) and replays all retained events
+// after the given revision to it. A revision of 0 only subscribes to new events.
+func (hub *watchHub) subscribe(key string, prefix bool, after uint64) (*Watcher, error) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return nil, ErrStoreDeleted
+	}
+
+	// Start retaining history from here on. Everything prior to that is out of reach.
+	if hub.history == nil {
+		hub.history = newEventRing(WATCH_HISTORY_LENGTH)
+		hub.first = hub.next
+	}
+
+	watcher := &Watcher{
+		hub:    hub,
+		key:    []byte(key),
+		prefix: prefix,
+	}
+
+	var replay []Event
+
+	if after != 0 && after < hub.next-1 {
+		// Revisions prior to the oldest retained event (or the start of the history) are gone.
+		oldest := hub.first
+		if hub.history.len() != 0 {
+			oldest = hub.history.at(0).Revision
+		}
+
+		if after+1 < oldest {
+			return nil, ErrRevisionCompacted
+		}
+
+		for i := 0; i < hub.history.len(); i++ {
+			if ev := hub.history.at(i); ev.Revision > after && watcher.matches(ev.Key) {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	watcher.events = make(chan Event, len(replay)+WATCH_BUFFER_LENGTH)
+	for _, ev := range replay {
+		watcher.events <- ev
+	}
+
+	hub.watchers[watcher] = struct{}{}
+
+	return watcher, nil
+}
+
+// unsubscribe removes the watcher and closes its channel. Must be called with the lock held.
+func (hub *watchHub) unsubscribe(watcher *Watcher, err error) {
+	if _, ok := hub.watchers[watcher]; !ok {
+		return
+	}
+
+	delete(hub.watchers, watcher)
+	watcher.err = err
+	close(watcher.events)
+}
+
+// close unsubscribes all watchers with ErrStoreDeleted and rejects further subscriptions.
+// Called when the Store gets deleted.
+func (hub *watchHub) close() {
+	hub.mu.Lock()
+	hub.closed = true
+	for watcher := range hub.watchers {
+		hub.unsubscribe(watcher, ErrStoreDeleted)
+	}
+	hub.mu.Unlock()
+}
+
+// eventRing is a fixed capacity buffer retaining the most recently pushed events.
+type eventRing struct {
+	events []Event
+	head   int
+	length int
+}
+
+func newEventRing(capacity int) *eventRing {
+	return &eventRing{
+		events: make([]Event, capacity),
+	}
+}
+
+// push appends the event, overwriting the oldest one once the ring is full.
+func (ring *eventRing) push(ev Event) {
+	ring.events[(ring.head+ring.length)%len(ring.events)] = ev
+
+	if ring.length < len(ring.events) {
+		ring.length++
+		return
+	}
+
+	ring.head = (ring.head + 1) % len(ring.events)
+}
+
+// at returns the i-th oldest retained event.
+func (ring *eventRing) at(i int) Event {
+	return ring.events[(ring.head+i)%len(ring.events)]
+}
+
+func (ring *eventRing) len() int {
+	return ring.length
+}
@@ -0,0 +1,244 @@
+package types
+
+import (
+	"strconv"
+	"testing"
+)
+
+func putEvent(revision uint64) Event {
+	key := "key" + strconv.FormatUint(revision, 10)
+	return Event{Type: EventPut, Key: key, Value: []byte(key), Revision: revision}
+}
+
+func publishRange(hub *watchHub, from, to uint64) {
+	for revision := from; revision <= to; revision++ {
+		hub.publish([]Event{putEvent(revision)})
+	}
+}
+
+func receive(t *testing.T, watcher *Watcher, n int) []Event {
+	t.Helper()
+
+	events := make([]Event, 0, n)
+	for i := 0; i < n; i++ {
+		select {
+		case ev, ok := <-watcher.Events():
+			if !ok {
+				t.Fatalf("watcher closed after %d of %d events: %v", i, n, watcher.Err())
+			}
+			events = append(events, ev)
+		default:
+			t.Fatalf("expected %d events, got %d", n, i)
+		}
+	}
+
+	return events
+}
+
+func expectRevisions(t *testing.T, events []Event, revisions ...uint64) {
+	t.Helper()
+
+	if len(events) != len(revisions) {
+		t.Fatalf("expected %d events, got %d", len(revisions), len(events))
+	}
+
+	for i, ev := range events {
+		if ev.Revision != revisions[i] {
+			t.Fatalf("expected revision %d at %d, got %d", revisions[i], i, ev.Revision)
+		}
+	}
+}
+
+func TestWatchHubPublishOutOfOrder(t *testing.T) {
+	hub := newWatchHub()
+
+	watcher, err := hub.subscribe("key", true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hub.publish([]Event{putEvent(3)})
+	hub.publish([]Event{putEvent(2), putEvent(5)})
+	receive(t, watcher, 0)
+
+	hub.publish([]Event{putEvent(1)})
+	expectRevisions(t, receive(t, watcher, 3), 1, 2, 3)
+
+	hub.publish([]Event{putEvent(4)})
+	expectRevisions(t, receive(t, watcher, 2), 4, 5)
+}
+
+func TestWatchHubPublishDropsLateEvents(t *testing.T) {
+	hub := newWatchHub()
+
+	watcher, err := hub.subscribe("key1", false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Leave a gap at 1 and overflow the reorder buffer, so that the hub skips it.
+	publishRange(hub, 2, WATCH_BUFFER_LENGTH+2)
+	if hub.next != WATCH_BUFFER_LENGTH+3 {
+		t.Fatalf("expected the hub to skip to %d, is at %d", WATCH_BUFFER_LENGTH+3, hub.next)
+	}
+
+	// The skipped event showing up late must neither get buffered nor delivered.
+	hub.publish([]Event{putEvent(1)})
+	if len(hub.pending) != 0 {
+		t.Fatalf("expected the late event to be dropped, %d pending", len(hub.pending))
+	}
+	receive(t, watcher, 0)
+
+	next := hub.next
+	hub.publish([]Event{{Type: EventDelete, Key: "key1", Revision: next}})
+	expectRevisions(t, receive(t, watcher, 1), next)
+}
+
+func TestWatchHubReplay(t *testing.T) {
+	hub := newWatchHub()
+
+	if _, err := hub.subscribe("", true, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	publishRange(hub, 1, 5)
+
+	watcher, err := hub.subscribe("", true, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := receive(t, watcher, 2)
+	expectRevisions(t, events, 4, 5)
+
+	for _, ev := range events {
+		if ev.Value != nil {
+			t.Fatalf("expected replayed event %d to carry no value", ev.Revision)
+		}
+	}
+
+	// New events follow the replay.
+	publishRange(hub, 6, 6)
+	events = receive(t, watcher, 1)
+	expectRevisions(t, events, 6)
+
+	if events[0].Value == nil {
+		t.Fatal("expected live event to carry its value")
+	}
+}
+
+func TestWatchHubReplayCompacted(t *testing.T) {
+	hub := newWatchHub()
+
+	if _, err := hub.subscribe("", true, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	last := uint64(WATCH_HISTORY_LENGTH + 10)
+	publishRange(hub, 1, last)
+
+	// The oldest retained event is last - WATCH_HISTORY_LENGTH + 1, so resuming right before it
+	// still works, while resuming any earlier does not.
+	oldest := last - WATCH_HISTORY_LENGTH + 1
+
+	if _, err := hub.subscribe("", true, oldest-2); err != ErrRevisionCompacted {
+		t.Fatalf("expected ErrRevisionCompacted, got %v", err)
+	}
+
+	watcher, err := hub.subscribe("", true, oldest-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := receive(t, watcher, WATCH_HISTORY_LENGTH)
+	if events[0].Revision != oldest || events[len(events)-1].Revision != last {
+		t.Fatalf("expected replay of %d..%d, got %d..%d", oldest, last, events[0].Revision, events[len(events)-1].Revision)
+	}
+}
+
+func TestWatchHubRetainsNoHistoryUntilWatched(t *testing.T) {
+	hub := newWatchHub()
+	hub.reset(10)
+
+	publishRange(hub, 11, 12)
+	if hub.history != nil {
+		t.Fatal("expected no history to be retained without watchers")
+	}
+
+	if _, err := hub.subscribe("", true, 10); err != ErrRevisionCompacted {
+		t.Fatalf("expected ErrRevisionCompacted, got %v", err)
+	}
+
+	// Resuming from the current revision has nothing to replay, so that works regardless.
+	if _, err := hub.subscribe("", true, 12); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchHubDropsLaggingWatcher(t *testing.T) {
+	hub := newWatchHub()
+
+	lagging, err := hub.subscribe("", true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := hub.subscribe("other", false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publishRange(hub, 1, WATCH_BUFFER_LENGTH+1)
+
+	receive(t, lagging, WATCH_BUFFER_LENGTH)
+	if _, ok := <-lagging.Events(); ok {
+		t.Fatal("expected the lagging watcher to be closed")
+	}
+
+	if err := lagging.Err(); err != ErrWatcherLagged {
+		t.Fatalf("expected ErrWatcherLagged, got %v", err)
+	}
+
+	// Watchers which keep up (or have nothing to receive) are unaffected.
+	if _, ok := hub.watchers[other]; !ok {
+		t.Fatal("expected the other watcher to remain subscribed")
+	}
+}
+
+func TestStoreDeleteClosesWatchers(t *testing.T) {
+	stores := openRepository(t)
+
+	store, err := stores.Create(0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := store.Watch("foo", false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := store.Put("foo", []byte("bar"), 0, Precondition{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := receive(t, watcher, 1)
+	expectRevisions(t, events, version)
+
+	if err := stores.Delete(store); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := <-watcher.Events(); ok {
+		t.Fatal("expected the watcher to be closed")
+	}
+
+	if err := watcher.Err(); err != ErrStoreDeleted {
+		t.Fatalf("expected ErrStoreDeleted, got %v", err)
+	}
+
+	if _, err := store.Watch("foo", false, 0); err != ErrStoreDeleted {
+		t.Fatalf("expected ErrStoreDeleted, got %v", err)
+	}
+}
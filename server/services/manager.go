@@ -1,6 +1,8 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -14,10 +16,15 @@ import (
 //
 type Manager struct {
 	interfaces []server.Interface
-	services   []Service
+	// In dependency order once bootstrapped - every service comes after the services it depends on.
+	services []Service
+
+	interfacesByName map[string]server.Interface
+	servicesByName   map[string]Service
 
 	logger *zap.Logger
 	ticker *time.Ticker
+	errs   chan error
 
 	onEachSecond []func(time time.Time)
 	onEachMinute []func(time time.Time)
@@ -29,48 +36,209 @@ type Manager struct {
 //
 func NewServiceManager(logger *zap.Logger, interfaces []server.Interface, services []Service) *Manager {
 	return &Manager{
+		errs:       make(chan error, len(interfaces)),
 		logger:     logger,
 		interfaces: interfaces,
 		services:   services,
 	}
 }
 
-//
-//
-//
-func (manager *Manager) Bootstrap() {
+// Bootstrap resolves the dependencies of the services and bootstraps them in dependency order.
+// Fails without bootstrapping any service if names are not unique, if hard dependencies are
+// missing, or if services depend on each other in a cycle. Otherwise fails on the first service
+// which fails to bootstrap.
+func (manager *Manager) Bootstrap() error {
+	manager.interfacesByName = make(map[string]server.Interface, len(manager.interfaces))
+	for _, iface := range manager.interfaces {
+		if _, exists := manager.interfacesByName[iface.GetName()]; exists {
+			return fmt.Errorf("services: interface %q registered more than once", iface.GetName())
+		}
+		manager.interfacesByName[iface.GetName()] = iface
+	}
+
+	manager.servicesByName = make(map[string]Service, len(manager.services))
+	for _, service := range manager.services {
+		if _, exists := manager.servicesByName[service.GetName()]; exists {
+			return fmt.Errorf("services: service %q registered more than once", service.GetName())
+		}
+		manager.servicesByName[service.GetName()] = service
+	}
+
+	for _, service := range manager.services {
+		for _, dep := range service.GetDependencies() {
+			if dep.Hard && !manager.has(dep) {
+				return fmt.Errorf("services: service %q requires %s, which is not registered", service.GetName(), dep)
+			}
+		}
+	}
+
+	ordered, err := manager.resolveOrder()
+	if err != nil {
+		return err
+	}
+	manager.services = ordered
+
 	manager.logger.Debug("Bootstrapping services")
 	for _, service := range manager.services {
-		service.Bootstrap(manager, manager.interfaces, manager.services)
+		if err := service.Bootstrap(manager); err != nil {
+			return fmt.Errorf("services: bootstrapping service %q: %v", service.GetName(), err)
+		}
 	}
+
+	return nil
 }
 
-//
-//
-//
-func (manager *Manager) Run() {
-	for _, iface := range manager.interfaces {
-		go func(iface server.Interface) {
-			manager.logger.Debug("Starting interface",
-				zap.String("interface", iface.GetKind()),
-				zap.String("action", "start"),
-			)
-			iface.Start()
-		}(iface)
+func (manager *Manager) has(dep Dependency) bool {
+	if dep.Service {
+		return manager.servicesByName[dep.Name] != nil
+	}
+
+	return manager.interfacesByName[dep.Name] != nil
+}
+
+// resolveOrder sorts the services topologically by their dependencies on each other, retaining
+// the order of registration otherwise.
+func (manager *Manager) resolveOrder() ([]Service, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	var (
+		ordered = make([]Service, 0, len(manager.services))
+		state   = make(map[string]int, len(manager.services))
+		visit   func(service Service) error
+	)
+
+	visit = func(service Service) error {
+		switch state[service.GetName()] {
+		case visited:
+			return nil
+		case visiting:
+			return errors.New("services: dependency cycle involving service \"" + service.GetName() + "\"")
+		}
+
+		state[service.GetName()] = visiting
+		for _, dep := range service.GetDependencies() {
+			if dep.Service && manager.servicesByName[dep.Name] != nil {
+				if err := visit(manager.servicesByName[dep.Name]); err != nil {
+					return err
+				}
+			}
+		}
+		state[service.GetName()] = visited
+
+		ordered = append(ordered, service)
+		return nil
 	}
 
 	for _, service := range manager.services {
-		go func(service Service) {
-			manager.logger.Debug("Starting service",
-				zap.String("service", service.GetName()),
-				zap.String("action", "start"),
-			)
-			service.Start()
-		}(service)
+		if err := visit(service); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// GetInterface returns the interface with the given name, or nil if none is registered.
+func (manager *Manager) GetInterface(name string) server.Interface {
+	return manager.interfacesByName[name]
+}
+
+// GetInterfaces returns all registered interfaces.
+func (manager *Manager) GetInterfaces() []server.Interface {
+	return manager.interfaces
+}
+
+// SelectInterfaces returns the interfaces with the given names which are registered, in the given
+// order - or all registered interfaces if no names are given.
+func (manager *Manager) SelectInterfaces(names []string) []server.Interface {
+	if len(names) == 0 {
+		return manager.interfaces
+	}
+
+	ifaces := make([]server.Interface, 0, len(names))
+	for _, name := range names {
+		if iface := manager.interfacesByName[name]; iface != nil {
+			ifaces = append(ifaces, iface)
+		}
+	}
+
+	return ifaces
+}
+
+// GetService returns the service with the given name, or nil if none is registered.
+func (manager *Manager) GetService(name string) Service {
+	return manager.servicesByName[name]
+}
+
+// Run starts the interfaces, then the services in dependency order, and then the tickers. If anything
+// fails to start, whatever got started already is stopped forcefully and the error returned.
+// Failures of interfaces after they have started get reported via Errors().
+func (manager *Manager) Run() error {
+	for i, iface := range manager.interfaces {
+		manager.logger.Debug("Starting interface",
+			zap.String("interface", iface.GetName()),
+			zap.String("action", "start"),
+		)
+
+		if err := iface.Start(); err != nil {
+			manager.stopStarted(manager.interfaces[:i], nil)
+			return fmt.Errorf("services: starting interface %q: %v", iface.GetName(), err)
+		}
+	}
+
+	// Services start in dependency order.
+	for i, service := range manager.services {
+		manager.logger.Debug("Starting service",
+			zap.String("service", service.GetName()),
+			zap.String("action", "start"),
+		)
+
+		if err := service.Start(); err != nil {
+			manager.stopStarted(manager.interfaces, manager.services[:i])
+			return fmt.Errorf("services: starting service %q: %v", service.GetName(), err)
+		}
+	}
+
+	for _, iface := range manager.interfaces {
+		go manager.forwardErrors(iface)
 	}
 
 	manager.ticker = time.NewTicker(time.Second * 1)
+	go manager.tick()
 
+	return nil
+}
+
+// Errors reports failures of interfaces after they have been started. The Manager does not act
+// on them on its own - that's up to whoever runs it.
+func (manager *Manager) Errors() <-chan error {
+	return manager.errs
+}
+
+func (manager *Manager) forwardErrors(iface server.Interface) {
+	for err := range iface.Errors() {
+		manager.errs <- fmt.Errorf("interface %q: %v", iface.GetName(), err)
+	}
+}
+
+// stopStarted forcefully stops the given services in reverse order, followed by the given interfaces.
+func (manager *Manager) stopStarted(ifaces []server.Interface, srvcs []Service) {
+	for i := len(srvcs) - 1; i >= 0; i-- {
+		srvcs[i].Stop(nil)
+	}
+
+	for _, iface := range ifaces {
+		iface.Stop(nil)
+	}
+}
+
+//
+//
+//
+func (manager *Manager) tick() {
 	for {
 		select {
 		// Note: Single per-second ticker appeared more efficient than separate tickers
@@ -96,7 +264,7 @@ func (manager *Manager) Run() {
 			}
 
 			// Naive minute 0 = new hour.
-			if tick.Minute() == 0 {
+			if tick.Minute() == 0 && tick.Second() == 0 {
 				go func() {
 					for _, handler := range manager.onEachHour {
 						handler(tick)
@@ -111,10 +279,12 @@ func (manager *Manager) Run() {
 //
 //
 func (manager *Manager) Stop(gracePeriod *time.Duration) {
-	var deadline time.Time
+	// Without a grace period, the deadline remains nil - which signals a forceful stop.
+	var deadline *time.Time
 
 	if gracePeriod != nil {
-		deadline = time.Now().Add(*gracePeriod)
+		d := time.Now().Add(*gracePeriod)
+		deadline = &d
 	}
 
 	if manager.ticker != nil {
@@ -122,28 +292,26 @@ func (manager *Manager) Stop(gracePeriod *time.Duration) {
 		manager.ticker.Stop()
 	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(len(manager.interfaces) + len(manager.services))
-
+	// Services stop in reverse dependency order, each before the services it depends on.
 	// @todo Deadlines for services.
-	for _, service := range manager.services {
-		go func(service Service) {
-			manager.logger.Debug("Stopping service",
-				zap.String("service", service.GetName()),
-				zap.String("action", "stop"),
-			)
-			service.Stop(&deadline)
-			wg.Done()
-		}(service)
+	for i := len(manager.services) - 1; i >= 0; i-- {
+		manager.logger.Debug("Stopping service",
+			zap.String("service", manager.services[i].GetName()),
+			zap.String("action", "stop"),
+		)
+		manager.services[i].Stop(deadline)
 	}
 
+	wg := sync.WaitGroup{}
+	wg.Add(len(manager.interfaces))
+
 	for _, iface := range manager.interfaces {
 		go func(iface server.Interface) {
 			manager.logger.Debug("Stopping interface",
-				zap.String("interface", iface.GetKind()),
+				zap.String("interface", iface.GetName()),
 				zap.String("action", "stop"),
 			)
-			iface.Stop(&deadline)
+			iface.Stop(deadline)
 			wg.Done()
 		}(iface)
 	}
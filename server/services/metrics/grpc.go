@@ -2,14 +2,32 @@ package metrics
 
 import (
 	"context"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/js13kgames/glitchd/server/metrics"
 )
 
 func (service *MetricsService) grpcRequestWrapper(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-	service.aggregator.BeginRequest()
-	res, err := handler(ctx, req)
-	service.aggregator.EndRequest()
+	start := time.Now()
+	service.aggregator.BeginRequest(metrics.InterfaceGrpc)
+	defer func() {
+		service.aggregator.EndRequest(metrics.InterfaceGrpc)
+		service.aggregator.ObserveRequest(metrics.InterfaceGrpc, info.FullMethod, status.Code(err).String(), time.Since(start))
+	}()
+
+	return handler(ctx, req)
+}
+
+func (service *MetricsService) grpcStreamWrapper(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	start := time.Now()
+	service.aggregator.BeginRequest(metrics.InterfaceGrpc)
+	defer func() {
+		service.aggregator.EndRequest(metrics.InterfaceGrpc)
+		service.aggregator.ObserveRequest(metrics.InterfaceGrpc, info.FullMethod, status.Code(err).String(), time.Since(start))
+	}()
 
-	return res, err
+	return handler(srv, ss)
 }
@@ -3,18 +3,33 @@ package metrics
 import (
 	"encoding/json"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/js13kgames/glitchd/server/interfaces/http"
+	"github.com/js13kgames/glitchd/server/metrics"
 )
 
 //
 //
 //
 func (service *MetricsService) httpRequestWrapper(ctx *gin.Context) {
-	service.aggregator.BeginRequest()
+	start := time.Now()
+	service.aggregator.BeginRequest(metrics.InterfaceHttp)
+	// Deferred, so that the request gets accounted for even if a handler further down panics.
+	defer service.aggregator.EndRequest(metrics.InterfaceHttp)
 	ctx.Next()
-	service.aggregator.EndRequest()
+
+	// Keyed by route template instead of the actual path, to keep the number of routes bounded.
+	route := ctx.FullPath()
+	if route == "" {
+		route = "unmatched"
+	} else {
+		route = ctx.Request.Method + " " + route
+	}
+
+	service.aggregator.ObserveRequest(metrics.InterfaceHttp, route, strconv.Itoa(ctx.Writer.Status()), time.Since(start))
 }
 
 //
@@ -35,4 +50,29 @@ func (service *MetricsService) registerHttpRoutes(router *gin.Engine) {
 			c.AbortWithError(500, err)
 		}
 	})
+
+	// Accepts from and to (Unix time in seconds) and resolution (second or minute) as query params.
+	router.GET("/metrics/history", http.BearerTokenInterceptor, http.PrivilegedTokenVerifier(service.key), func(c *gin.Context) {
+		from, to, resolution, err := metrics.ParseHistoryQuery(c.Query("from"), c.Query("to"), c.Query("resolution"), time.Now())
+		if err != nil {
+			c.AbortWithStatus(400)
+			return
+		}
+
+		c.JSON(200, service.aggregator.History(from, to, resolution))
+	})
+
+	router.GET("/metrics/prometheus", http.BearerTokenInterceptor, http.PrivilegedTokenVerifier(service.key), func(c *gin.Context) {
+		c.Writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		w := metrics.NewPrometheusWriter(c.Writer)
+		service.aggregator.WritePrometheus(w)
+		for _, collector := range service.collectors {
+			collector(w)
+		}
+
+		if err := w.Flush(); err != nil {
+			c.AbortWithError(500, err)
+		}
+	})
 }
@@ -5,21 +5,26 @@ import (
 
 	"google.golang.org/grpc"
 
-	"github.com/js13kgames/glitchd/server"
 	"github.com/js13kgames/glitchd/server/interfaces"
+	"github.com/js13kgames/glitchd/server/interfaces/http"
 	"github.com/js13kgames/glitchd/server/metrics"
 	"github.com/js13kgames/glitchd/server/services"
 )
 
 type MetricsService struct {
-	key        string
+	key        *http.PrivilegedKey
+	ifaces     []string
 	aggregator *metrics.GlobalAggregator
+	collectors []metrics.PrometheusCollector
 }
 
-func NewMetricsService(aggregator *metrics.GlobalAggregator, key string) *MetricsService {
+// NewMetricsService creates a MetricsService which instruments and serves its routes on the interfaces
+// with the given names - or on all interfaces if none are given.
+func NewMetricsService(aggregator *metrics.GlobalAggregator, key *http.PrivilegedKey, ifaces []string) *MetricsService {
 	return &MetricsService{
 		aggregator: aggregator,
 		key:        key,
+		ifaces:     ifaces,
 	}
 }
 
@@ -29,10 +34,15 @@ func (service *MetricsService) GetName() string {
 }
 
 //
-func (service *MetricsService) Bootstrap(manager *services.Manager, ifaces []server.Interface, srvcs []services.Service) {
+func (service *MetricsService) GetDependencies() []services.Dependency {
+	return services.RequiresInterfaces(service.ifaces)
+}
+
+//
+func (service *MetricsService) Bootstrap(manager *services.Manager) error {
 	service.aggregator.Bootstrap(manager)
 
-	for _, iface := range ifaces {
+	for _, iface := range manager.SelectInterfaces(service.ifaces) {
 		switch v := iface.(type) {
 		case *interfaces.HttpServerInterface:
 			service.registerHttpMiddleware(v.GetHandler())
@@ -40,13 +50,23 @@ func (service *MetricsService) Bootstrap(manager *services.Manager, ifaces []ser
 
 		case *interfaces.GrpcServerInterface:
 			v.PrependUnaryInterceptor(grpc.UnaryServerInterceptor(service.grpcRequestWrapper))
+			v.PrependStreamInterceptor(grpc.StreamServerInterceptor(service.grpcStreamWrapper))
 		}
 	}
+
+	return nil
+}
+
+// RegisterCollector adds metrics collected by other services to the Prometheus endpoint.
+// Note: *Not* thread safe. Meant to be called during bootstrap only.
+func (service *MetricsService) RegisterCollector(collector metrics.PrometheusCollector) {
+	service.collectors = append(service.collectors, collector)
 }
 
 //
-func (service *MetricsService) Start() {
+func (service *MetricsService) Start() error {
 	// No-op - we only register with global interfaces.
+	return nil
 }
 
 //
@@ -6,6 +6,56 @@ type Service interface {
 	server.Runnable
 	//
 	GetName() string
-	//
-	Bootstrap(manager *Manager, interfaces []server.Interface, services []Service)
+	// GetDependencies declares the interfaces and other services the service depends on. The Manager
+	// bootstraps and starts services after the services they depend on, and stops them before those.
+	GetDependencies() []Dependency
+	// Bootstrap registers the service with its dependencies, which can be looked up by name via the
+	// Manager. Soft dependencies which are not registered are nil.
+	Bootstrap(manager *Manager) error
+}
+
+// Dependency of a Service on either a named interface or another service. Hard dependencies must be
+// registered with the Manager for the service to run, soft dependencies get used if they are.
+type Dependency struct {
+	Name    string
+	Service bool
+	Hard    bool
+}
+
+// RequiresInterface declares a hard dependency on the interface with the given name.
+func RequiresInterface(name string) Dependency {
+	return Dependency{Name: name, Hard: true}
+}
+
+// RequiresInterfaces declares hard dependencies on each of the interfaces with the given names.
+func RequiresInterfaces(names []string) []Dependency {
+	deps := make([]Dependency, len(names))
+	for i, name := range names {
+		deps[i] = RequiresInterface(name)
+	}
+
+	return deps
+}
+
+// WantsInterface declares a soft dependency on the interface with the given name.
+func WantsInterface(name string) Dependency {
+	return Dependency{Name: name}
+}
+
+// RequiresService declares a hard dependency on the service with the given name.
+func RequiresService(name string) Dependency {
+	return Dependency{Name: name, Service: true, Hard: true}
+}
+
+// WantsService declares a soft dependency on the service with the given name.
+func WantsService(name string) Dependency {
+	return Dependency{Name: name, Service: true}
+}
+
+func (dep Dependency) String() string {
+	if dep.Service {
+		return "service \"" + dep.Name + "\""
+	}
+
+	return "interface \"" + dep.Name + "\""
 }